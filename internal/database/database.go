@@ -3,7 +3,6 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
 
 	_ "github.com/lib/pq"
 )
@@ -30,49 +29,3 @@ func NewDB(connStr string) (*DB, error) {
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
-
-// RunMigrations runs database migrations
-func (db *DB) RunMigrations() error {
-	migrations := []string{
-		`CREATE TABLE IF NOT EXISTS teams (
-			team_name VARCHAR(255) PRIMARY KEY,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS users (
-			user_id VARCHAR(255) PRIMARY KEY,
-			username VARCHAR(255) NOT NULL,
-			team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
-			is_active BOOLEAN NOT NULL DEFAULT true,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS pull_requests (
-			pull_request_id VARCHAR(255) PRIMARY KEY,
-			pull_request_name VARCHAR(255) NOT NULL,
-			author_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE RESTRICT,
-			status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			merged_at TIMESTAMP
-		)`,
-		`CREATE TABLE IF NOT EXISTS pr_reviewers (
-			pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
-			reviewer_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
-			PRIMARY KEY (pull_request_id, reviewer_id)
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_team_name ON users(team_name)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_is_active ON users(is_active)`,
-		`CREATE INDEX IF NOT EXISTS idx_pr_author_id ON pull_requests(author_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_pr_status ON pull_requests(status)`,
-		`CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_id ON pr_reviewers(reviewer_id)`,
-	}
-
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("failed to run migration: %w", err)
-		}
-	}
-
-	log.Println("Database migrations completed successfully")
-	return nil
-}
-
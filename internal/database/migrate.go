@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating,
+// so that several replicas starting at once do not migrate concurrently
+const migrationLockID = 7_245_019_311
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys
+// and returns them ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func embeddedMigrations() ([]Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return LoadMigrations(sub)
+}
+
+// RunMigrations applies all pending migrations
+func (db *DB) RunMigrations() error {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return db.withMigrationLock(func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if applied[m.Version] {
+				continue
+			}
+			if err := applyMigration(conn, m.Up, func(tx *sql.Tx) error {
+				_, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+
+		log.Println("Database migrations completed successfully")
+		return nil
+	})
+}
+
+// RollbackMigration reverts the most recently applied migration
+func (db *DB) RollbackMigration() error {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return db.withMigrationLock(func(conn *sql.Conn) error {
+		var version int64
+		err := conn.QueryRowContext(context.Background(), "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			log.Println("No migrations to roll back")
+			return nil
+		}
+
+		for _, m := range migrations {
+			if m.Version != version {
+				continue
+			}
+			if err := applyMigration(conn, m.Down, func(tx *sql.Tx) error {
+				_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", m.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("failed to roll back migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Rolled back migration %d_%s", m.Version, m.Name)
+			return nil
+		}
+
+		return fmt.Errorf("applied migration %d is unknown to this build", version)
+	})
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the migration
+// advisory lock and makes sure the schema_migrations table exists
+func (db *DB) withMigrationLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(conn *sql.Conn) (map[int64]bool, error) {
+	rows, err := conn.QueryContext(context.Background(), "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration executes a migration script and its bookkeeping in one transaction
+func applyMigration(conn *sql.Conn, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("Expected migration versions without gaps, got %d at position %d", m.Version, i)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("SELECT 2")},
+		"0002_second.down.sql": {Data: []byte("SELECT -2")},
+		"0001_first.up.sql":    {Data: []byte("SELECT 1")},
+		"0001_first.down.sql":  {Data: []byte("SELECT -1")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Name != "first" || migrations[0].Up != "SELECT 1" || migrations[0].Down != "SELECT -1" {
+		t.Errorf("Unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[1].Version != 2 {
+		t.Errorf("Expected migrations ordered by version, got %+v", migrations)
+	}
+}
+
+func TestLoadMigrationsRequiresDown(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_first.up.sql": {Data: []byte("SELECT 1")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("Expected error for migration without down file")
+	}
+
+	fsys = fstest.MapFS{
+		"first.sql": {Data: []byte("SELECT 1")},
+	}
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Fatal("Expected error for unexpected file name")
+	}
+}
@@ -0,0 +1,4 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS round_robin_cursor;
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewer_strategy;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS assignment_strategy;
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS assignment_strategy VARCHAR(32);
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32) NOT NULL DEFAULT 'least_loaded';
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS round_robin_cursor VARCHAR(255);
@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS reviewers_count INTEGER NOT NULL DEFAULT 2;
@@ -0,0 +1,2 @@
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS state VARCHAR(32) NOT NULL DEFAULT 'PENDING';
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS force_merged;
+ALTER TABLE teams DROP COLUMN IF EXISTS block_on_changes_requested;
+ALTER TABLE teams DROP COLUMN IF EXISTS min_approvals;
@@ -0,0 +1,3 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS min_approvals INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS block_on_changes_requested BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS force_merged BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+UPDATE pull_requests SET status = 'OPEN' WHERE status IN ('DRAFT', 'CLOSED');
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS reviewers_count;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS reviewers_count INTEGER;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_availability (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    reassign_open_reviews BOOLEAN NOT NULL DEFAULT false,
+    reassigned_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_availability_user_id ON user_availability(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_availability_period ON user_availability(starts_at, ends_at);
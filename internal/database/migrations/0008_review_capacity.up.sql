@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS default_max_open_reviews INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS understaffed BOOLEAN NOT NULL DEFAULT false;
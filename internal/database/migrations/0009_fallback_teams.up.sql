@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    fallback_team VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (team_name, fallback_team),
+    UNIQUE (team_name, position),
+    CHECK (team_name <> fallback_team)
+);
+
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS code_owner_rules (
+    position INTEGER PRIMARY KEY,
+    pattern TEXT NOT NULL,
+    owner_users TEXT[] NOT NULL DEFAULT '{}',
+    owner_teams TEXT[] NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS changed_files TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider VARCHAR(16) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, login)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS assignment_history (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    action VARCHAR(16) NOT NULL,
+    reviewer_id VARCHAR(255) NOT NULL,
+    previous_reviewer_id VARCHAR(255),
+    fallback_team VARCHAR(255),
+    reason VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_history_pr ON assignment_history(pull_request_id, id);
+CREATE INDEX IF NOT EXISTS idx_assignment_history_reviewer ON assignment_history(reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_assignment_history_previous_reviewer ON assignment_history(previous_reviewer_id);
+
+-- Current assignments become the start of the history
+INSERT INTO assignment_history (pull_request_id, action, reviewer_id, fallback_team, reason, actor, occurred_at)
+SELECT prr.pull_request_id, 'ASSIGNED', prr.reviewer_id, prr.fallback_team, 'INITIAL', 'system',
+       COALESCE(prr.assigned_at, pr.created_at, CURRENT_TIMESTAMP)
+FROM pr_reviewers prr
+JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id
+ORDER BY prr.pull_request_id, prr.assigned_at, prr.reviewer_id;
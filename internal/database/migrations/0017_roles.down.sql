@@ -0,0 +1,2 @@
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS user_id;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
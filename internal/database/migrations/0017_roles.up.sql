@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'member';
+ALTER TABLE api_tokens ADD COLUMN IF NOT EXISTS user_id VARCHAR(255) REFERENCES users(user_id) ON DELETE CASCADE;
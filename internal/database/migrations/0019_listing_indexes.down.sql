@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_pr_reviewers_reviewer_pr;
+DROP INDEX IF EXISTS idx_pr_author_created_at;
+DROP INDEX IF EXISTS idx_pr_status_created_at;
+DROP INDEX IF EXISTS idx_users_username_lower;
+DROP INDEX IF EXISTS idx_users_team_user_id_c;
+DROP INDEX IF EXISTS idx_users_user_id_c;
+DROP INDEX IF EXISTS idx_teams_name_lower;
+DROP INDEX IF EXISTS idx_teams_name_c;
@@ -0,0 +1,8 @@
+CREATE INDEX IF NOT EXISTS idx_teams_name_c ON teams(team_name COLLATE "C");
+CREATE INDEX IF NOT EXISTS idx_teams_name_lower ON teams(lower(team_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_user_id_c ON users(user_id COLLATE "C");
+CREATE INDEX IF NOT EXISTS idx_users_team_user_id_c ON users(team_name, user_id COLLATE "C");
+CREATE INDEX IF NOT EXISTS idx_users_username_lower ON users(lower(username) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_pr_status_created_at ON pull_requests(status, created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pr_author_created_at ON pull_requests(author_id, created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer_pr ON pr_reviewers(reviewer_id, pull_request_id);
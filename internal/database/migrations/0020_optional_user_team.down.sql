@@ -0,0 +1,2 @@
+-- Fails while users removed from their team remain; move them to a team first
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
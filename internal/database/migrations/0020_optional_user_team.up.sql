@@ -0,0 +1,2 @@
+-- Users removed from their team keep their PRs and history
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
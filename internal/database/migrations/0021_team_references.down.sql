@@ -0,0 +1,11 @@
+ALTER TABLE team_fallbacks DROP CONSTRAINT IF EXISTS team_fallbacks_fallback_team_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_fallback_team_fkey
+    FOREIGN KEY (fallback_team) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks DROP CONSTRAINT IF EXISTS team_fallbacks_team_name_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
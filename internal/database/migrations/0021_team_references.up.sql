@@ -0,0 +1,12 @@
+-- Renames follow team references; deleting a team no longer deletes its members
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_name_fkey;
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE team_fallbacks DROP CONSTRAINT IF EXISTS team_fallbacks_team_name_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks DROP CONSTRAINT IF EXISTS team_fallbacks_fallback_team_fkey;
+ALTER TABLE team_fallbacks ADD CONSTRAINT team_fallbacks_fallback_team_fkey
+    FOREIGN KEY (fallback_team) REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE CASCADE;
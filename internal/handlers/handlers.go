@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/avito-tech/pr-reviewer-service/internal/models"
@@ -26,219 +32,1069 @@ func (h *Handlers) CreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.CreateTeam(team); err != nil {
-		code := service.GetErrorCode(err)
-		if code == "TEAM_EXISTS" {
-			h.writeError(w, http.StatusBadRequest, code, service.GetErrorMessage(err))
+	if err := h.service.CreateTeam(r.Context(), team); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	// Return the created team
+	createdTeam, err := h.service.GetTeam(r.Context(), team.TeamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team": createdTeam,
+	})
+}
+
+func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	team, err := h.service.GetTeam(r.Context(), teamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+func (h *Handlers) ListTeams(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	filter := models.TeamFilter{NamePrefix: query.Get("name_prefix"), Limit: limit}
+	page, err := h.service.ListTeams(r.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *Handlers) GetTeamSettings(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	settings, err := h.service.GetTeamSettings(r.Context(), teamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+func (h *Handlers) UpdateTeamSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.TeamSettingsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	settings, err := h.service.UpdateTeamSettings(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+func (h *Handlers) GetFallbackTeams(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	fallbacks, err := h.service.GetFallbackTeams(r.Context(), teamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fallbacks)
+}
+
+func (h *Handlers) SetFallbackTeams(w http.ResponseWriter, r *http.Request) {
+	var req models.TeamFallbacks
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	fallbacks, err := h.service.SetFallbackTeams(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fallbacks)
+}
+
+func (h *Handlers) AddTeamMembers(w http.ResponseWriter, r *http.Request) {
+	var req models.Team
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	if err := h.service.AddTeamMembers(r.Context(), req.TeamName, req.Members); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	team, err := h.service.GetTeam(r.Context(), req.TeamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team": team,
+	})
+}
+
+func (h *Handlers) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName     string              `json:"team_name"`
+		UserID       string              `json:"user_id"`
+		ReviewPolicy models.ReviewPolicy `json:"review_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" || req.UserID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name and user_id are required")
+		return
+	}
+
+	change, err := h.service.RemoveTeamMember(r.Context(), req.TeamName, req.UserID, req.ReviewPolicy)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+func (h *Handlers) MoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string              `json:"user_id"`
+		TeamName     string              `json:"team_name"`
+		ReviewPolicy models.ReviewPolicy `json:"review_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" || req.UserID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id and team_name are required")
+		return
+	}
+
+	change, err := h.service.MoveTeamMember(r.Context(), req.UserID, req.TeamName, req.ReviewPolicy)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
+
+func (h *Handlers) RenameTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		NewTeamName string `json:"new_team_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	if err := h.service.RenameTeam(r.Context(), req.TeamName, req.NewTeamName); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	team, err := h.service.GetTeam(r.Context(), req.NewTeamName)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"team": team,
+	})
+}
+
+func (h *Handlers) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	teamName := query.Get("team_name")
+	if teamName == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	deletion, err := h.service.DeleteTeam(r.Context(), teamName, query.Get("target_team"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deletion)
+}
+
+func (h *Handlers) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.UserFilter{
+		TeamName:   query.Get("team_name"),
+		NamePrefix: query.Get("name_prefix"),
+	}
+	if value := query.Get("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "is_active must be true or false")
 			return
 		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		filter.IsActive = &isActive
+	}
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	filter.Limit = limit
+
+	page, err := h.service.ListUsers(r.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *Handlers) SetUserActive(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID   string `json:"user_id"`
+		IsActive bool   `json:"is_active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	user, err := h.service.SetUserActive(r.Context(), req.UserID, req.IsActive)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": user,
+	})
+}
+
+func (h *Handlers) SetUserMaxOpenReviews(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	user, err := h.service.SetUserMaxOpenReviews(r.Context(), req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": user,
+	})
+}
+
+func (h *Handlers) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string      `json:"user_id"`
+		Role   models.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	user, err := h.service.SetUserRole(r.Context(), req.UserID, req.Role)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": user,
+	})
+}
+
+func (h *Handlers) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID   string   `json:"pull_request_id"`
+		PullRequestName string   `json:"pull_request_name"`
+		AuthorID        string   `json:"author_id"`
+		ReviewersCount  *int     `json:"reviewers_count"`
+		Draft           bool     `json:"draft"`
+		ChangedFiles    []string `json:"changed_files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := h.service.CreatePullRequest(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID, service.CreatePullRequestOptions{
+		ReviewersCount: req.ReviewersCount,
+		Draft:          req.Draft,
+		ChangedFiles:   req.ChangedFiles,
+	})
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func (h *Handlers) MergePullRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		Force         bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := h.service.MergePullRequest(r.Context(), req.PullRequestID, service.MergePullRequestOptions{
+		Force: req.Force,
+	})
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func (h *Handlers) ClosePullRequest(w http.ResponseWriter, r *http.Request) {
+	h.transitionPullRequest(w, r, h.service.ClosePullRequest)
+}
+
+func (h *Handlers) ReopenPullRequest(w http.ResponseWriter, r *http.Request) {
+	h.transitionPullRequest(w, r, h.service.ReopenPullRequest)
+}
+
+func (h *Handlers) MarkPullRequestReady(w http.ResponseWriter, r *http.Request) {
+	h.transitionPullRequest(w, r, h.service.MarkPullRequestReady)
+}
+
+func (h *Handlers) transitionPullRequest(w http.ResponseWriter, r *http.Request, transition func(ctx context.Context, prID string) (*models.PullRequest, error)) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := transition(r.Context(), req.PullRequestID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func (h *Handlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		OldUserID     string `json:"old_user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, replacedBy, err := h.service.ReassignReviewer(r.Context(), req.PullRequestID, req.OldUserID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr":         pr,
+		"replaced_by": replacedBy,
+	})
+}
+
+func (h *Handlers) ReviewPullRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string             `json:"pull_request_id"`
+		ReviewerID    string             `json:"reviewer_id"`
+		Verdict       models.ReviewState `json:"verdict"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := h.service.SubmitReview(r.Context(), req.PullRequestID, req.ReviewerID, req.Verdict)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func (h *Handlers) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	history, err := h.service.GetAssignmentHistory(r.Context(), prID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pull_request_id": prID,
+		"history":         history,
+	})
+}
+
+func (h *Handlers) ListPullRequests(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePullRequestFilter(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	filter.ReviewerID = r.URL.Query().Get("reviewer_id")
+
+	page, err := h.service.ListPullRequests(r.Context(), filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *Handlers) GetPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.GetPullRequest(r.Context(), prID)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func (h *Handlers) GetUserReviewPRs(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required")
+		return
+	}
+
+	filter, err := parsePullRequestFilter(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	page, err := h.service.GetUserReviewPRs(r.Context(), userID, filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"user_id":       userID,
+		"pull_requests": page.PullRequests,
+	}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parsePullRequestFilter reads the status, author_id, team_name, from, to,
+// min_age, max_age, sort and limit query parameters
+func parsePullRequestFilter(query url.Values) (models.PullRequestFilter, error) {
+	filter := models.PullRequestFilter{
+		AuthorID: query.Get("author_id"),
+		TeamName: query.Get("team_name"),
+		Sort:     models.PullRequestSort(query.Get("sort")),
+	}
+	if value := query.Get("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			filter.Statuses = append(filter.Statuses, models.PullRequestStatus(strings.TrimSpace(status)))
+		}
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, errors.New(name + " must be an RFC 3339 timestamp")
+			}
+			*target = &t
+		}
+	}
+
+	// Ages bound the creation time too; the stricter bound applies
+	now := time.Now()
+	for name, older := range map[string]bool{"min_age": true, "max_age": false} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		age, err := time.ParseDuration(value)
+		if err != nil || age < 0 {
+			return filter, errors.New(name + " must be a non-negative duration such as 72h")
+		}
+		t := now.Add(-age)
+		switch {
+		case older && (filter.To == nil || t.Before(*filter.To)):
+			filter.To = &t
+		case !older && (filter.From == nil || t.After(*filter.From)):
+			filter.From = &t
+		}
+	}
+
+	var err error
+	filter.Limit, err = parseLimit(query)
+	return filter, err
+}
+
+// parseLimit reads the limit query parameter; 0 means it is not given
+func parseLimit(query url.Values) (int, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("limit must be an integer")
+	}
+	return limit, nil
+}
+
+func (h *Handlers) AddAvailabilityWindow(w http.ResponseWriter, r *http.Request) {
+	var req models.AvailabilityWindow
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
 
-	// Return the created team
-	createdTeam, err := h.service.GetTeam(team.TeamName)
+	window, err := h.service.AddAvailabilityWindow(r.Context(), req)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"team": createdTeam,
+		"window": window,
 	})
 }
 
-func (h *Handlers) GetTeam(w http.ResponseWriter, r *http.Request) {
-	teamName := r.URL.Query().Get("team_name")
-	if teamName == "" {
-		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+func (h *Handlers) ListAvailabilityWindows(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required")
 		return
 	}
 
-	team, err := h.service.GetTeam(teamName)
+	windows, err := h.service.ListAvailabilityWindows(r.Context(), userID)
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(team)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"windows": windows,
+	})
 }
 
-func (h *Handlers) SetUserActive(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) DeleteAvailabilityWindow(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		UserID   string `json:"user_id"`
-		IsActive bool   `json:"is_active"`
+		UserID string `json:"user_id"`
+		ID     int64  `json:"id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
 
-	user, err := h.service.SetUserActive(req.UserID, req.IsActive)
+	if err := h.service.DeleteAvailabilityWindow(r.Context(), req.UserID, req.ID); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": req.UserID,
+		"id":      req.ID,
+	})
+}
+
+func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+func (h *Handlers) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	h.writeErrorDetail(w, statusCode, models.ErrorDetail{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// writeServiceError maps an error returned by the service to an HTTP response.
+// Domain errors carry their own code and status; anything else is reported
+// as INTERNAL_ERROR.
+func (h *Handlers) writeServiceError(w http.ResponseWriter, err error) {
+	svcErr, ok := service.AsError(err)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, service.CodeInternal, err.Error())
+		return
+	}
+
+	h.writeErrorDetail(w, svcErr.HTTPStatus, models.ErrorDetail{
+		Code:    svcErr.Code,
+		Message: svcErr.Message,
+		Details: svcErr.Details,
+	})
+}
+
+func (h *Handlers) writeErrorDetail(w http.ResponseWriter, statusCode int, detail models.ErrorDetail) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error: detail,
+	})
+}
+
+func (h *Handlers) GetCodeOwners(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.GetCodeOwners(r.Context())
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"user": user,
+		"rules": rules,
 	})
 }
 
-func (h *Handlers) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) UploadCodeOwners(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
+		Content string `json:"content"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
 
-	pr, err := h.service.CreatePullRequest(req.PullRequestID, req.PullRequestName, req.AuthorID)
+	rules, err := h.service.UploadCodeOwners(r.Context(), req.Content)
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "PR_EXISTS" {
-			h.writeError(w, http.StatusConflict, code, service.GetErrorMessage(err))
-			return
-		}
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": pr,
+		"rules": rules,
 	})
 }
 
-func (h *Handlers) MergePullRequest(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ValidateCodeOwners(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PullRequestID string `json:"pull_request_id"`
+		Content string `json:"content"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
 
-	pr, err := h.service.MergePullRequest(req.PullRequestID)
+	validation, err := h.service.ValidateCodeOwners(r.Context(), req.Content)
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validation)
+}
+
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL    string             `json:"url"`
+		Secret string             `json:"secret"`
+		Events []models.EventType `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), req.URL, req.Secret, req.Events)
+	if err != nil {
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": pr,
+		"webhook": webhook,
 	})
 }
 
-func (h *Handlers) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": webhooks,
+	})
+}
+
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PullRequestID string `json:"pull_request_id"`
-		OldUserID     string `json:"old_user_id"`
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), req.ID); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id": req.ID,
+	})
+}
+
+func (h *Handlers) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "webhook_id must be an integer")
+		return
+	}
+
+	deliveries, err := h.service.ListWebhookDeliveries(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhook_id": id,
+		"deliveries": deliveries,
+	})
+}
+
+func (h *Handlers) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	identities, err := h.service.ListIdentities(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"identities": identities,
+	})
+}
+
+func (h *Handlers) SetIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.UserIdentity
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
 		return
 	}
 
-	pr, replacedBy, err := h.service.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	identity, err := h.service.SetIdentity(r.Context(), req)
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" || code == "PR_MERGED" || code == "NOT_ASSIGNED" || code == "NO_CANDIDATE" {
-			statusCode := http.StatusNotFound
-			if code == "PR_MERGED" || code == "NOT_ASSIGNED" || code == "NO_CANDIDATE" {
-				statusCode = http.StatusConflict
-			}
-			h.writeError(w, statusCode, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr":         pr,
-		"replaced_by": replacedBy,
+		"identity": identity,
 	})
 }
 
-func (h *Handlers) GetUserReviewPRs(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required")
+func (h *Handlers) DeleteIdentity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Provider models.IdentityProvider `json:"provider"`
+		Login    string                  `json:"login"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.service.DeleteIdentity(r.Context(), req.Provider, req.Login); err != nil {
+		h.writeServiceError(w, err)
 		return
 	}
 
-	prs, err := h.service.GetUserReviewPRs(userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider": req.Provider,
+		"login":    req.Login,
+	})
+}
+
+// maxIntegrationPayload limits the size of inbound webhook bodies
+const maxIntegrationPayload = 5 << 20
+
+func (h *Handlers) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxIntegrationPayload))
 	if err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	result, err := h.service.HandleGitHubWebhook(r.Context(), r.Header.Get(service.GitHubEventHeader),
+		r.Header.Get(service.GitHubSignatureHeader), body)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handlers) GitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxIntegrationPayload))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	result, err := h.service.HandleGitLabWebhook(r.Context(), r.Header.Get(service.GitLabEventHeader),
+		r.Header.Get(service.GitLabTokenHeader), body)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handlers) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Actor:      query.Get("actor"),
+	}
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, service.CodeInvalidRequest, name+" must be an RFC 3339 timestamp")
+				return
+			}
+			*target = &t
 		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+	limit, err := parseLimit(query)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, service.CodeInvalidRequest, err.Error())
+		return
+	}
+	filter.Limit = limit
+
+	page, err := h.service.ListAuditLog(r.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *Handlers) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string         `json:"name"`
+		Scopes []models.Scope `json:"scopes"`
+		UserID string         `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	token, value, err := h.service.CreateToken(r.Context(), req.Name, req.Scopes, req.UserID)
+	if err != nil {
+		h.writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"user_id":       userID,
-		"pull_requests": prs,
+		"token":  token,
+		"secret": value,
 	})
 }
 
-func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.service.ListTokens(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "ok",
-		"time":   time.Now().Format(time.RFC3339),
+		"tokens": tokens,
 	})
 }
 
-func (h *Handlers) writeError(w http.ResponseWriter, statusCode int, code, message string) {
+func (h *Handlers) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.service.DeleteToken(r.Context(), req.ID); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{
-		Error: models.ErrorDetail{
-			Code:    code,
-			Message: message,
-		},
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id": req.ID,
 	})
 }
 
 func (h *Handlers) GetStatistics(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.service.GetStatistics()
+	stats, err := h.service.GetStatistics(r.Context())
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		h.writeServiceError(w, err)
 		return
 	}
 
@@ -256,18 +1112,13 @@ func (h *Handlers) BulkDeactivateUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.BulkDeactivateUsers(req.TeamName, req.UserIDs); err != nil {
-		code := service.GetErrorCode(err)
-		if code == "NOT_FOUND" {
-			h.writeError(w, http.StatusNotFound, code, service.GetErrorMessage(err))
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	if err := h.service.BulkDeactivateUsers(r.Context(), req.TeamName, req.UserIDs); err != nil {
+		h.writeServiceError(w, err)
 		return
 	}
 
 	// Safe reassign open PRs
-	reassignedCount, err := h.service.SafeReassignOpenPRs(req.UserIDs)
+	reassignedCount, err := h.service.SafeReassignOpenPRs(r.Context(), req.UserIDs)
 	if err != nil {
 		// Log but don't fail the request
 		log.Printf("Warning: failed to safely reassign PRs: %v", err)
@@ -280,16 +1131,60 @@ func (h *Handlers) BulkDeactivateUsers(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// RegisterRoutes registers the API on router. Every endpoint except the
+// health check and the GitHub and GitLab webhooks, which check their own
+// signatures, requires a bearer token with the scope listed here.
 func (h *Handlers) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/team/add", h.CreateTeam).Methods("POST")
-	router.HandleFunc("/team/get", h.GetTeam).Methods("GET")
-	router.HandleFunc("/users/setIsActive", h.SetUserActive).Methods("POST")
-	router.HandleFunc("/pullRequest/create", h.CreatePullRequest).Methods("POST")
-	router.HandleFunc("/pullRequest/merge", h.MergePullRequest).Methods("POST")
-	router.HandleFunc("/pullRequest/reassign", h.ReassignReviewer).Methods("POST")
-	router.HandleFunc("/users/getReview", h.GetUserReviewPRs).Methods("GET")
+	read, teamWrite, prWrite, admin := models.ScopeRead, models.ScopeTeamWrite, models.ScopePRWrite, models.ScopeAdmin
+
+	router.Handle("/team/add", h.authorize(teamWrite, h.CreateTeam)).Methods("POST")
+	router.Handle("/team/get", h.authorize(read, h.GetTeam)).Methods("GET")
+	router.Handle("/team/list", h.authorize(read, h.ListTeams)).Methods("GET")
+	router.Handle("/team/settings", h.authorize(read, h.GetTeamSettings)).Methods("GET")
+	router.Handle("/team/settings", h.authorize(teamWrite, h.UpdateTeamSettings)).Methods("POST")
+	router.Handle("/team/fallbacks", h.authorize(read, h.GetFallbackTeams)).Methods("GET")
+	router.Handle("/team/fallbacks", h.authorize(teamWrite, h.SetFallbackTeams)).Methods("POST")
+	router.Handle("/team/addMembers", h.authorize(teamWrite, h.AddTeamMembers)).Methods("POST")
+	router.Handle("/team/removeMember", h.authorize(teamWrite, h.RemoveTeamMember)).Methods("POST")
+	router.Handle("/team/moveMember", h.authorize(teamWrite, h.MoveTeamMember)).Methods("POST")
+	router.Handle("/team/rename", h.authorize(teamWrite, h.RenameTeam)).Methods("POST")
+	router.Handle("/team", h.authorize(admin, h.DeleteTeam)).Methods("DELETE")
+	router.Handle("/users/list", h.authorize(read, h.ListUsers)).Methods("GET")
+	router.Handle("/users/setIsActive", h.authorize(admin, h.SetUserActive)).Methods("POST")
+	router.Handle("/users/setMaxOpenReviews", h.authorize(teamWrite, h.SetUserMaxOpenReviews)).Methods("POST")
+	router.Handle("/users/setRole", h.authorize(admin, h.SetUserRole)).Methods("POST")
+	router.Handle("/pullRequest/list", h.authorize(read, h.ListPullRequests)).Methods("GET")
+	router.Handle("/pullRequest/get", h.authorize(read, h.GetPullRequest)).Methods("GET")
+	router.Handle("/pullRequest/create", h.authorize(prWrite, h.CreatePullRequest)).Methods("POST")
+	router.Handle("/pullRequest/merge", h.authorize(prWrite, h.MergePullRequest)).Methods("POST")
+	router.Handle("/pullRequest/close", h.authorize(prWrite, h.ClosePullRequest)).Methods("POST")
+	router.Handle("/pullRequest/reopen", h.authorize(prWrite, h.ReopenPullRequest)).Methods("POST")
+	router.Handle("/pullRequest/ready", h.authorize(prWrite, h.MarkPullRequestReady)).Methods("POST")
+	router.Handle("/pullRequest/reassign", h.authorize(prWrite, h.ReassignReviewer)).Methods("POST")
+	router.Handle("/pullRequest/review", h.authorize(prWrite, h.ReviewPullRequest)).Methods("POST")
+	router.Handle("/pullRequest/history", h.authorize(read, h.GetAssignmentHistory)).Methods("GET")
+	router.Handle("/users/getReview", h.authorize(read, h.GetUserReviewPRs)).Methods("GET")
+	router.Handle("/users/availability", h.authorize(read, h.ListAvailabilityWindows)).Methods("GET")
+	router.Handle("/users/availability", h.authorize(teamWrite, h.AddAvailabilityWindow)).Methods("POST")
+	router.Handle("/users/availability/delete", h.authorize(teamWrite, h.DeleteAvailabilityWindow)).Methods("POST")
+	router.Handle("/codeowners", h.authorize(read, h.GetCodeOwners)).Methods("GET")
+	router.Handle("/codeowners", h.authorize(teamWrite, h.UploadCodeOwners)).Methods("POST")
+	router.Handle("/codeowners/validate", h.authorize(read, h.ValidateCodeOwners)).Methods("POST")
+	router.Handle("/webhooks", h.authorize(admin, h.ListWebhooks)).Methods("GET")
+	router.Handle("/webhooks", h.authorize(admin, h.CreateWebhook)).Methods("POST")
+	router.Handle("/webhooks/delete", h.authorize(admin, h.DeleteWebhook)).Methods("POST")
+	router.Handle("/webhooks/deliveries", h.authorize(admin, h.ListWebhookDeliveries)).Methods("GET")
+	router.Handle("/identities", h.authorize(read, h.ListIdentities)).Methods("GET")
+	router.Handle("/identities", h.authorize(admin, h.SetIdentity)).Methods("POST")
+	router.Handle("/identities/delete", h.authorize(admin, h.DeleteIdentity)).Methods("POST")
+	router.HandleFunc("/integrations/github", h.GitHubWebhook).Methods("POST")
+	router.HandleFunc("/integrations/gitlab", h.GitLabWebhook).Methods("POST")
+	router.Handle("/audit", h.authorize(admin, h.ListAuditLog)).Methods("GET")
+	router.Handle("/tokens", h.authorize(admin, h.ListTokens)).Methods("GET")
+	router.Handle("/tokens", h.authorize(admin, h.CreateToken)).Methods("POST")
+	router.Handle("/tokens/delete", h.authorize(admin, h.DeleteToken)).Methods("POST")
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
-	router.HandleFunc("/stats", h.GetStatistics).Methods("GET")
-	router.HandleFunc("/users/bulkDeactivate", h.BulkDeactivateUsers).Methods("POST")
+	router.Handle("/stats", h.authorize(read, h.GetStatistics)).Methods("GET")
+	router.Handle("/users/bulkDeactivate", h.authorize(admin, h.BulkDeactivateUsers)).Methods("POST")
 }
 
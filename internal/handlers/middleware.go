@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// TimeoutMiddleware bounds the time a request may spend in the service.
+// Storage operations still running at the deadline are cancelled and the
+// request fails with a TIMEOUT error.
+func TimeoutMiddleware(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authorize requires a bearer token granting scope before calling next.
+// Changes made by the request are attributed to the token in the audit log,
+// and the service checks the role of the token's user where it matters.
+func (h *Handlers) authorize(scope models.Scope, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			value = ""
+		}
+
+		caller, err := h.service.Authenticate(r.Context(), strings.TrimSpace(value))
+		if err == nil {
+			err = service.RequireScope(&caller.Token, scope)
+		}
+		if err != nil {
+			if service.IsErrorCode(err, service.CodeUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="pr-reviewer-service"`)
+			}
+			h.writeServiceError(w, err)
+			return
+		}
+
+		ctx := service.WithActor(r.Context(), caller.Token.Name)
+		next.ServeHTTP(w, r.WithContext(service.WithCaller(ctx, *caller)))
+	})
+}
@@ -1,45 +1,254 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
 	UserID   string `json:"user_id" db:"user_id"`
 	Username string `json:"username" db:"username"`
+	// TeamName is empty for users removed from their team
 	TeamName string `json:"team_name" db:"team_name"`
 	IsActive bool   `json:"is_active" db:"is_active"`
+	// MaxOpenReviews limits the number of OPEN PRs the user reviews at once.
+	// nil falls back to the team default.
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty" db:"max_open_reviews"`
+	Role           Role `json:"role" db:"role"`
 }
 
+// Role decides what a user may do with pull requests beyond their own reviews
+type Role string
+
+const (
+	// RoleAdmin may manage pull requests of every team
+	RoleAdmin Role = "admin"
+	// RoleTeamLead may manage pull requests authored by their team
+	RoleTeamLead Role = "team_lead"
+	// RoleMember may only submit verdicts on their own reviews
+	RoleMember Role = "member"
+)
+
+// Roles lists all roles
+var Roles = []Role{RoleAdmin, RoleTeamLead, RoleMember}
+
 // Team represents a team with its members
 type Team struct {
-	TeamName string      `json:"team_name"`
+	TeamName string       `json:"team_name"`
 	Members  []TeamMember `json:"members"`
 }
 
+// TeamSummary is a team in a listing
+type TeamSummary struct {
+	TeamName      string `json:"team_name"`
+	MemberCount   int    `json:"member_count"`
+	ActiveMembers int    `json:"active_members"`
+}
+
+// TeamFilter selects teams. Empty fields match every team.
+type TeamFilter struct {
+	// NamePrefix selects teams whose name starts with it, ignoring case
+	NamePrefix string
+	// After selects teams following the named one, for pagination
+	After string
+	Limit int
+}
+
+// TeamPage is a page of the team listing, ordered by name
+type TeamPage struct {
+	Teams []TeamSummary `json:"teams"`
+	// NextCursor fetches the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// UserFilter selects users. Empty fields match every user.
+type UserFilter struct {
+	TeamName string
+	IsActive *bool
+	// NamePrefix selects users whose username starts with it, ignoring case
+	NamePrefix string
+	// After selects users following the given user_id, for pagination
+	After string
+	Limit int
+}
+
+// UserPage is a page of the user listing, ordered by user_id
+type UserPage struct {
+	Users []User `json:"users"`
+	// NextCursor fetches the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// TeamDeletion is the outcome of deleting a team
+type TeamDeletion struct {
+	TeamName string `json:"team_name"`
+	// TargetTeam received the members; empty if they were left without a team
+	TargetTeam string `json:"target_team,omitempty"`
+	// Members are the users who were in the team
+	Members []string `json:"members"`
+}
+
+// TeamFallbacks is the ordered list of teams that supply reviewers when a
+// team has no suitable candidates left
+type TeamFallbacks struct {
+	TeamName      string   `json:"team_name"`
+	FallbackTeams []string `json:"fallback_teams"`
+}
+
 // TeamMember represents a member of a team
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	IsActive       bool   `json:"is_active"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
+	// Role defaults to the user's current role, or member for new users
+	Role Role `json:"role,omitempty"`
+}
+
+// ReviewPolicy decides what happens to the open reviews of a user who
+// leaves a team
+type ReviewPolicy string
+
+const (
+	// ReviewPolicyKeep leaves the user assigned
+	ReviewPolicyKeep ReviewPolicy = "keep"
+	// ReviewPolicyReassignNewTeam hands the reviews to the team the user joins
+	ReviewPolicyReassignNewTeam ReviewPolicy = "reassign_new_team"
+	// ReviewPolicyReassignOldTeam hands the reviews to the team the user leaves
+	ReviewPolicyReassignOldTeam ReviewPolicy = "reassign_old_team"
+)
+
+// ReviewerReplacement is a review handed from one reviewer to another
+type ReviewerReplacement struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+// MembershipChange is the outcome of moving a user to another team or
+// removing them from their team
+type MembershipChange struct {
+	User User `json:"user"`
+	// Reassigned lists the open reviews handed to other reviewers
+	Reassigned []ReviewerReplacement `json:"reassigned"`
+}
+
+// AvailabilityWindow is a period when a user is out of office and must not
+// be assigned as a reviewer
+type AvailabilityWindow struct {
+	ID       int64     `json:"id" db:"id"`
+	UserID   string    `json:"user_id" db:"user_id"`
+	StartsAt time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt   time.Time `json:"ends_at" db:"ends_at"`
+	Reason   string    `json:"reason" db:"reason"`
+	// ReassignOpenReviews moves the user's open reviews to other reviewers
+	// once the window starts
+	ReassignOpenReviews bool `json:"reassign_open_reviews" db:"reassign_open_reviews"`
+	// ReassignedAt is set once the open reviews have been reassigned
+	ReassignedAt *time.Time `json:"reassigned_at,omitempty" db:"reassigned_at"`
+}
+
+// Covers reports whether the window includes the given moment
+func (w AvailabilityWindow) Covers(t time.Time) bool {
+	return !t.Before(w.StartsAt) && t.Before(w.EndsAt)
 }
 
 // PullRequestStatus represents the status of a PR
 type PullRequestStatus string
 
 const (
+	StatusDraft  PullRequestStatus = "DRAFT"
 	StatusOpen   PullRequestStatus = "OPEN"
 	StatusMerged PullRequestStatus = "MERGED"
+	StatusClosed PullRequestStatus = "CLOSED"
 )
 
+// SelectionStrategy represents the way reviewers are picked for a PR
+type SelectionStrategy string
+
+const (
+	// StrategyRandom picks reviewers at random
+	StrategyRandom SelectionStrategy = "random"
+	// StrategyRoundRobin rotates through the team members in order
+	StrategyRoundRobin SelectionStrategy = "round_robin"
+	// StrategyLeastLoaded prefers reviewers with the fewest open assignments
+	StrategyLeastLoaded SelectionStrategy = "least_loaded"
+	// StrategyLeastRecentlyAssigned prefers reviewers assigned the longest time ago
+	StrategyLeastRecentlyAssigned SelectionStrategy = "least_recently_assigned"
+)
+
+// TeamSettings represents per-team reviewer assignment settings
+type TeamSettings struct {
+	TeamName         string            `json:"team_name"`
+	ReviewerStrategy SelectionStrategy `json:"reviewer_strategy"`
+	ReviewersCount   int               `json:"reviewers_count"`
+	// MinApprovals is the number of APPROVED verdicts required to merge
+	MinApprovals int `json:"min_approvals"`
+	// BlockOnChangesRequested forbids merging while any reviewer requests changes
+	BlockOnChangesRequested bool `json:"block_on_changes_requested"`
+	// DefaultMaxOpenReviews is the open review limit of members without
+	// their own limit; 0 means unlimited
+	DefaultMaxOpenReviews int `json:"default_max_open_reviews"`
+}
+
+// TeamSettingsUpdate represents a partial update of team settings
+type TeamSettingsUpdate struct {
+	TeamName                string             `json:"team_name"`
+	ReviewerStrategy        *SelectionStrategy `json:"reviewer_strategy,omitempty"`
+	ReviewersCount          *int               `json:"reviewers_count,omitempty"`
+	MinApprovals            *int               `json:"min_approvals,omitempty"`
+	BlockOnChangesRequested *bool              `json:"block_on_changes_requested,omitempty"`
+	DefaultMaxOpenReviews   *int               `json:"default_max_open_reviews,omitempty"`
+}
+
 // PullRequest represents a pull request
 type PullRequest struct {
-	PullRequestID    string             `json:"pull_request_id" db:"pull_request_id"`
-	PullRequestName  string             `json:"pull_request_name" db:"pull_request_name"`
-	AuthorID         string             `json:"author_id" db:"author_id"`
-	Status           PullRequestStatus  `json:"status" db:"status"`
-	AssignedReviewers []string          `json:"assigned_reviewers"`
-	CreatedAt        *time.Time         `json:"createdAt,omitempty" db:"created_at"`
-	MergedAt         *time.Time         `json:"mergedAt,omitempty" db:"merged_at"`
+	PullRequestID      string            `json:"pull_request_id" db:"pull_request_id"`
+	PullRequestName    string            `json:"pull_request_name" db:"pull_request_name"`
+	AuthorID           string            `json:"author_id" db:"author_id"`
+	Status             PullRequestStatus `json:"status" db:"status"`
+	AssignedReviewers  []Reviewer        `json:"assigned_reviewers"`
+	AssignmentStrategy SelectionStrategy `json:"assignment_strategy,omitempty" db:"assignment_strategy"`
+	ForceMerged        bool              `json:"force_merged,omitempty" db:"force_merged"`
+	Understaffed       bool              `json:"understaffed,omitempty" db:"understaffed"`
+	CreatedAt          *time.Time        `json:"createdAt,omitempty" db:"created_at"`
+	MergedAt           *time.Time        `json:"mergedAt,omitempty" db:"merged_at"`
+	ClosedAt           *time.Time        `json:"closedAt,omitempty" db:"closed_at"`
+	// ReviewersCount is the reviewer count requested on creation, if any
+	ReviewersCount *int `json:"-" db:"reviewers_count"`
+	// ChangedFiles are the file paths touched by the PR, used to find code owners
+	ChangedFiles []string `json:"changed_files,omitempty" db:"changed_files"`
+}
+
+// ReviewerIDs returns user ids of the assigned reviewers
+func (pr *PullRequest) ReviewerIDs() []string {
+	ids := make([]string, 0, len(pr.AssignedReviewers))
+	for _, reviewer := range pr.AssignedReviewers {
+		ids = append(ids, reviewer.UserID)
+	}
+	return ids
+}
+
+// ReviewState represents the current verdict of a reviewer on a PR
+type ReviewState string
+
+const (
+	ReviewPending          ReviewState = "PENDING"
+	ReviewApproved         ReviewState = "APPROVED"
+	ReviewChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewCommented        ReviewState = "COMMENTED"
+)
+
+// Reviewer represents a reviewer assigned to a PR and their current verdict
+type Reviewer struct {
+	UserID     string      `json:"user_id" db:"reviewer_id"`
+	State      ReviewState `json:"state" db:"state"`
+	AssignedAt *time.Time  `json:"assigned_at,omitempty" db:"assigned_at"`
+	ReviewedAt *time.Time  `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	// FallbackTeam is set when the reviewer was borrowed from a fallback
+	// team of the home team
+	FallbackTeam string `json:"fallback_team,omitempty" db:"fallback_team"`
 }
 
 // PullRequestShort represents a short version of PR
@@ -48,6 +257,355 @@ type PullRequestShort struct {
 	PullRequestName string            `json:"pull_request_name"`
 	AuthorID        string            `json:"author_id"`
 	Status          PullRequestStatus `json:"status"`
+	CreatedAt       *time.Time        `json:"createdAt,omitempty"`
+}
+
+// PullRequestSort orders pull request listings
+type PullRequestSort string
+
+const (
+	// SortNewestFirst orders pull requests by creation time, newest first
+	SortNewestFirst PullRequestSort = "-created_at"
+	// SortOldestFirst orders pull requests by creation time, oldest first
+	SortOldestFirst PullRequestSort = "created_at"
+)
+
+// PullRequestKey is the position of a pull request in a listing. Pull
+// requests created at the same moment are ordered by id.
+type PullRequestKey struct {
+	CreatedAt     time.Time
+	PullRequestID string
+}
+
+// PullRequestFilter selects pull requests. Empty fields match every PR.
+type PullRequestFilter struct {
+	// ReviewerID selects PRs the user is assigned to review
+	ReviewerID string
+	Statuses   []PullRequestStatus
+	AuthorID   string
+	// TeamName selects PRs whose author is a member of the team
+	TeamName string
+	// From and To bound the creation time; From is inclusive, To exclusive
+	From *time.Time
+	To   *time.Time
+	Sort PullRequestSort
+	// After selects PRs following the given one in sort order, for pagination
+	After *PullRequestKey
+	Limit int
+}
+
+// PullRequestPage is a page of a pull request listing
+type PullRequestPage struct {
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	// NextCursor fetches the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CodeOwnerRule maps a CODEOWNERS path pattern to the users and teams owning
+// matching files
+type CodeOwnerRule struct {
+	Pattern string   `json:"pattern" db:"pattern"`
+	Users   []string `json:"users" db:"owner_users"`
+	Teams   []string `json:"teams" db:"owner_teams"`
+}
+
+// CodeOwnersIssue is a problem found on a line of a CODEOWNERS file
+type CodeOwnersIssue struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// CodeOwnersValidation is the result of checking a CODEOWNERS file
+type CodeOwnersValidation struct {
+	Valid  bool              `json:"valid"`
+	Rules  []CodeOwnerRule   `json:"rules"`
+	Errors []CodeOwnersIssue `json:"errors"`
+}
+
+// IdentityProvider is an external code hosting service sending PR webhooks
+type IdentityProvider string
+
+const (
+	ProviderGitHub IdentityProvider = "github"
+	ProviderGitLab IdentityProvider = "gitlab"
+)
+
+// UserIdentity maps a login on an external provider to a user
+type UserIdentity struct {
+	Provider IdentityProvider `json:"provider" db:"provider"`
+	Login    string           `json:"login" db:"login"`
+	UserID   string           `json:"user_id" db:"user_id"`
+}
+
+// IntegrationResult describes what the service did with an inbound webhook
+type IntegrationResult struct {
+	// Action is one of created, ready, merged, closed, reopened or ignored
+	Action      string       `json:"action"`
+	PullRequest *PullRequest `json:"pr,omitempty"`
+}
+
+// EventType identifies a kind of event sent to webhook subscribers
+type EventType string
+
+const (
+	EventTeamCreated        EventType = "team.created"
+	EventPRCreated          EventType = "pr.created"
+	EventReviewerAssigned   EventType = "reviewer.assigned"
+	EventReviewerReassigned EventType = "reviewer.reassigned"
+	EventPRMerged           EventType = "pr.merged"
+	EventUserDeactivated    EventType = "user.deactivated"
+)
+
+// EventTypes lists all event types in the order they are documented
+var EventTypes = []EventType{
+	EventTeamCreated,
+	EventPRCreated,
+	EventReviewerAssigned,
+	EventReviewerReassigned,
+	EventPRMerged,
+	EventUserDeactivated,
+}
+
+// Event is the body of a webhook request
+type Event struct {
+	ID         string      `json:"id"`
+	Type       EventType   `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// OutboxEvent is an event stored in the transaction of the change that caused
+// it, waiting to be published to the event sinks
+type OutboxEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	EventID   string    `json:"event_id" db:"event_id"`
+	EventType EventType `json:"event_type" db:"event_type"`
+	// Payload is the JSON encoded Event
+	Payload     []byte     `json:"-" db:"payload"`
+	OccurredAt  time.Time  `json:"occurred_at" db:"occurred_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty" db:"published_at"`
+	// Attempts and LastError describe failed attempts to publish the event
+	Attempts  int    `json:"attempts" db:"attempts"`
+	LastError string `json:"last_error,omitempty" db:"last_error"`
+}
+
+// WebhookSubscription is a URL notified about events. The secret signs the
+// requests and is never returned by the API.
+type WebhookSubscription struct {
+	ID     int64  `json:"id" db:"id"`
+	URL    string `json:"url" db:"url"`
+	Secret string `json:"-" db:"secret"`
+	// Events filters the event types sent to the URL; empty means all
+	Events    []EventType `json:"events" db:"events"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+}
+
+// Wants reports whether the subscription receives events of the given type
+func (s WebhookSubscription) Wants(eventType EventType) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus represents the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "PENDING"
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	DeliveryFailed    DeliveryStatus = "FAILED"
+)
+
+// WebhookDelivery is a single event queued for a subscriber
+type WebhookDelivery struct {
+	ID             int64     `json:"id" db:"id"`
+	SubscriptionID int64     `json:"webhook_id" db:"subscription_id"`
+	EventID        string    `json:"event_id" db:"event_id"`
+	EventType      EventType `json:"event_type" db:"event_type"`
+	// Payload is the exact request body, as signed
+	Payload       []byte         `json:"-" db:"payload"`
+	Status        DeliveryStatus `json:"status" db:"status"`
+	Attempts      int            `json:"attempts" db:"attempts"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError     string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// Scope grants an API token access to a group of endpoints
+type Scope string
+
+const (
+	// ScopeAdmin grants every scope, including user deactivation, webhooks,
+	// the audit log and token management
+	ScopeAdmin Scope = "admin"
+	// ScopeTeamWrite allows changing teams, their settings and members
+	ScopeTeamWrite Scope = "team:write"
+	// ScopePRWrite allows creating and changing pull requests
+	ScopePRWrite Scope = "pr:write"
+	// ScopeRead allows reading everything except admin data
+	ScopeRead Scope = "read"
+)
+
+// Scopes lists all scopes
+var Scopes = []Scope{ScopeAdmin, ScopeTeamWrite, ScopePRWrite, ScopeRead}
+
+// APIToken is a bearer token accepted by the API. The token itself is shown
+// once on creation; only its hash is stored.
+type APIToken struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Scopes    []Scope   `json:"scopes" db:"scopes"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// UserID binds the token to a user: requests made with it act with the
+	// user's role. Empty for service tokens.
+	UserID string `json:"user_id,omitempty" db:"user_id"`
+	// Hash is the hex SHA-256 of the token
+	Hash string `json:"-" db:"token_hash"`
+}
+
+// Allows reports whether the token grants a scope. Admin tokens grant every
+// scope and write scopes include read.
+func (t APIToken) Allows(scope Scope) bool {
+	for _, granted := range t.Scopes {
+		switch {
+		case granted == scope, granted == ScopeAdmin:
+			return true
+		case scope == ScopeRead && (granted == ScopeTeamWrite || granted == ScopePRWrite):
+			return true
+		}
+	}
+	return false
+}
+
+// Entity types of audit entries
+const (
+	AuditEntityTeam        = "team"
+	AuditEntityUser        = "user"
+	AuditEntityPullRequest = "pull_request"
+	AuditEntityToken       = "token"
+	// AuditEntityAvailability entries are identified by the window ID
+	AuditEntityAvailability = "availability_window"
+	// AuditEntityIdentity entries are identified by "<provider>:<login>"
+	AuditEntityIdentity = "identity"
+	// AuditEntityWebhook entries are identified by the subscription ID
+	AuditEntityWebhook = "webhook"
+)
+
+// AuditEntry records a state-changing operation together with the state of
+// the entity before and after it. Entries are never changed or removed.
+type AuditEntry struct {
+	ID         int64           `json:"id" db:"id"`
+	Actor      string          `json:"actor" db:"actor"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityID   string          `json:"entity_id" db:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"before"`
+	After      json.RawMessage `json:"after,omitempty" db:"after"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter selects audit entries. Empty fields match every entry.
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	// From and To bound the creation time; From is inclusive, To exclusive
+	From *time.Time
+	To   *time.Time
+	// BeforeID selects entries older than the given one, for pagination
+	BeforeID int64
+	Limit    int
+}
+
+// AuditPage is a page of the audit log
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	// NextCursor fetches the next, older page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// AssignmentAction is the kind of change in the assignment history
+type AssignmentAction string
+
+const (
+	// AssignmentAssigned adds a reviewer to a pull request
+	AssignmentAssigned AssignmentAction = "ASSIGNED"
+	// AssignmentReassigned replaces PreviousReviewerID with ReviewerID
+	AssignmentReassigned AssignmentAction = "REASSIGNED"
+	// AssignmentRemoved takes ReviewerID off a pull request without a
+	// replacement
+	AssignmentRemoved AssignmentAction = "REMOVED"
+)
+
+// AssignmentReason explains why an assignment changed
+type AssignmentReason string
+
+const (
+	// ReasonInitial marks reviewers picked when a PR is opened
+	ReasonInitial AssignmentReason = "INITIAL"
+	// ReasonManual marks changes requested through the API
+	ReasonManual AssignmentReason = "MANUAL"
+	// ReasonDeactivation marks reviews taken from deactivated users
+	ReasonDeactivation AssignmentReason = "DEACTIVATION"
+	// ReasonOutOfOffice marks reviews taken from users out of office
+	ReasonOutOfOffice AssignmentReason = "OOO"
+	// ReasonSLA marks reviews taken from reviewers who missed the review
+	// deadline
+	ReasonSLA AssignmentReason = "SLA"
+	// ReasonTeamChange marks reviews taken from users who moved to another
+	// team or were removed from their team
+	ReasonTeamChange AssignmentReason = "TEAM_CHANGE"
+)
+
+// AssignmentEvent is an entry of the assignment history of a pull request.
+// Unlike the reviewers of a pull request, which are updated in place, the
+// history keeps every reviewer who has ever been assigned.
+type AssignmentEvent struct {
+	ID                 int64            `json:"id" db:"id"`
+	PullRequestID      string           `json:"pull_request_id" db:"pull_request_id"`
+	Action             AssignmentAction `json:"action" db:"action"`
+	ReviewerID         string           `json:"reviewer_id" db:"reviewer_id"`
+	PreviousReviewerID string           `json:"previous_reviewer_id,omitempty" db:"previous_reviewer_id"`
+	FallbackTeam       string           `json:"fallback_team,omitempty" db:"fallback_team"`
+	Reason             AssignmentReason `json:"reason" db:"reason"`
+	Actor              string           `json:"actor" db:"actor"`
+	OccurredAt         time.Time        `json:"occurred_at" db:"occurred_at"`
+}
+
+// Statistics represents statistics about the service
+type Statistics struct {
+	UserAssignments []UserAssignmentStats `json:"user_assignments"`
+	PRStats         PRStatistics          `json:"pr_statistics"`
+}
+
+// UserAssignmentStats represents assignment statistics for a user
+type UserAssignmentStats struct {
+	UserID           string `json:"user_id"`
+	Username         string `json:"username"`
+	TotalAssignments int    `json:"total_assignments"`
+	// ReassignedAway counts reviews taken from the user and given to
+	// someone else
+	ReassignedAway int `json:"reassigned_away"`
+	OpenPRs        int `json:"open_prs"`
+	MergedPRs      int `json:"merged_prs"`
+}
+
+// PRStatistics represents overall PR statistics
+type PRStatistics struct {
+	TotalPRs            int `json:"total_prs"`
+	OpenPRs             int `json:"open_prs"`
+	MergedPRs           int `json:"merged_prs"`
+	ClosedPRs           int `json:"closed_prs"`
+	DraftPRs            int `json:"draft_prs"`
+	PRsWithReviewers    int `json:"prs_with_reviewers"`
+	PRsWithoutReviewers int `json:"prs_without_reviewers"`
 }
 
 // ErrorResponse represents an error response
@@ -55,9 +613,9 @@ type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
 }
 
-// ErrorDetail contains error code and message
+// ErrorDetail contains error code, message and optional structured details
 type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
 }
-
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// SystemActor is recorded in the audit log and the assignment history for
+// changes made without an actor in the context, such as background jobs
+const SystemActor = "system"
+
+// Audit log page sizes
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are attributed to actor in the
+// audit log
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFrom returns the actor of a context or SystemActor
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+// recordAudit appends an entry to the audit log in the transaction of the
+// change. before is nil for created entities and after for deleted ones.
+func recordAudit(ctx context.Context, tx storage.Tx, action, entityType, entityID string, before, after interface{}) error {
+	entry := &models.AuditEntry{
+		Actor:      actorFrom(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	var err error
+	if entry.Before, err = auditState(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditState(after); err != nil {
+		return err
+	}
+	return tx.Audit().Append(entry)
+}
+
+func auditState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// ListAuditLog returns audit entries matching the filter, newest first. The
+// cursor is the NextCursor of the previous page or empty for the first one.
+func (s *Service) ListAuditLog(ctx context.Context, filter models.AuditFilter, cursor string) (*models.AuditPage, error) {
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultAuditLimit
+	case filter.Limit < 0 || filter.Limit > maxAuditLimit:
+		return nil, newError(CodeInvalidRequest, "limit must be between 1 and %d", maxAuditLimit)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, newError(CodeInvalidRequest, "from must be before to")
+	}
+	if cursor != "" {
+		id, err := decodeIDCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeID = id
+	}
+
+	// One extra entry tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	var entries []models.AuditEntry
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		entries, err = tx.Audit().List(filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AuditPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = encodeIDCursor(page.Entries[limit-1].ID)
+	}
+	if page.Entries == nil {
+		page.Entries = []models.AuditEntry{}
+	}
+	return page, nil
+}
+
+// encodeIDCursor returns an opaque pagination cursor pointing at an id
+func encodeIDCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeIDCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, newError(CodeInvalidRequest, "invalid cursor")
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, newError(CodeInvalidRequest, "invalid cursor")
+	}
+	return id, nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Caller is who a request is made by: the token it presents and, for tokens
+// bound to a user, that user's role and team
+type Caller struct {
+	Token models.APIToken
+	// UserID is empty for service tokens
+	UserID   string
+	TeamName string
+	// Role is admin for service tokens with the admin scope and empty for
+	// other service tokens
+	Role models.Role
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context whose service calls are authorized as caller.
+// Calls without a caller, such as background jobs and signed integration
+// webhooks, are trusted.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// callerFrom returns the caller of a context, if any
+func callerFrom(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
+
+// CanManagePullRequest decides whether a caller may reassign reviewers of or
+// merge a PR whose author is in authorTeam: only admins and team leads of
+// that team may
+func CanManagePullRequest(caller Caller, authorTeam string) error {
+	switch {
+	case caller.Role == models.RoleAdmin:
+		return nil
+	case caller.Role == models.RoleTeamLead && caller.TeamName != "" && caller.TeamName == authorTeam:
+		return nil
+	}
+	return newError(CodeForbidden, "only an admin or a team lead of %s can manage this PR", authorTeam).
+		withDetail("author_team", authorTeam)
+}
+
+// CanManageTeam decides whether a caller may change the settings, fallback
+// teams, members or name of a team or delete it: only admins and team leads of
+// that team may
+func CanManageTeam(caller Caller, teamName string) error {
+	switch {
+	case caller.Role == models.RoleAdmin:
+		return nil
+	case caller.Role == models.RoleTeamLead && caller.TeamName != "" && caller.TeamName == teamName:
+		return nil
+	}
+	return newError(CodeForbidden, "only an admin or a team lead of %s can manage this team", teamName).
+		withDetail("team_name", teamName)
+}
+
+// CanManageAbsence decides whether a caller may register or remove an
+// out-of-office period of a user: the user themselves and whoever may manage
+// the user's team may
+func CanManageAbsence(caller Caller, user models.User) error {
+	if caller.UserID != "" && caller.UserID == user.UserID {
+		return nil
+	}
+	return CanManageTeam(caller, user.TeamName)
+}
+
+// CanSetRole decides whether a caller may give a user a role: only admins may
+func CanSetRole(caller Caller) error {
+	if caller.Role == models.RoleAdmin {
+		return nil
+	}
+	return newError(CodeForbidden, "only an admin can set user roles")
+}
+
+// CanForceMerge decides whether a caller may merge a PR that does not satisfy
+// the merge policy: only admins may
+func CanForceMerge(caller Caller) error {
+	if caller.Role == models.RoleAdmin {
+		return nil
+	}
+	return newError(CodeForbidden, "only an admin can force a merge")
+}
+
+// CanSubmitReview decides whether a caller may submit a verdict on behalf of
+// a reviewer: only the reviewer themselves may
+func CanSubmitReview(caller Caller, reviewerID string) error {
+	if caller.UserID != "" && caller.UserID == reviewerID {
+		return nil
+	}
+	return newError(CodeForbidden, "only %s can submit their verdict", reviewerID)
+}
+
+// authorizeManage applies CanManagePullRequest to the caller of ctx
+func authorizeManage(ctx context.Context, tx storage.Tx, pr *models.PullRequest) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	author, err := tx.Users().Get(pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	return CanManagePullRequest(caller, author.TeamName)
+}
+
+// authorizeTeam applies CanManageTeam to the caller of ctx
+func authorizeTeam(ctx context.Context, teamName string) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return CanManageTeam(caller, teamName)
+}
+
+// authorizeAbsence applies CanManageAbsence to the caller of ctx
+func authorizeAbsence(ctx context.Context, user models.User) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return CanManageAbsence(caller, user)
+}
+
+// authorizeRole applies CanSetRole to the caller of ctx
+func authorizeRole(ctx context.Context) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return CanSetRole(caller)
+}
+
+// authorizeForce applies CanForceMerge to the caller of ctx
+func authorizeForce(ctx context.Context) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return CanForceMerge(caller)
+}
+
+// authorizeReview applies CanSubmitReview to the caller of ctx
+func authorizeReview(ctx context.Context, reviewerID string) error {
+	caller, ok := callerFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return CanSubmitReview(caller, reviewerID)
+}
+
+// resolveCaller looks up the user a token is bound to
+func resolveCaller(tx storage.Tx, token models.APIToken) (*Caller, error) {
+	caller := &Caller{Token: token}
+	if token.UserID == "" {
+		if token.Allows(models.ScopeAdmin) {
+			caller.Role = models.RoleAdmin
+		}
+		return caller, nil
+	}
+
+	user, err := tx.Users().Get(token.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, newError(CodeUnauthorized, "user of the token no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	caller.UserID = user.UserID
+	caller.TeamName = user.TeamName
+	caller.Role = user.Role
+	return caller, nil
+}
+
+// SetUserRole changes the role of a user
+func (s *Service) SetUserRole(ctx context.Context, userID string, role models.Role) (*models.User, error) {
+	if !isRole(role) {
+		return nil, newError(CodeInvalidRequest, "role must be one of admin, team_lead, member")
+	}
+
+	var user *models.User
+	err := s.update(ctx, func(tx storage.Tx) error {
+		previous, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Users().SetRole(userID, role); err != nil {
+			return err
+		}
+
+		user, err = tx.Users().Get(userID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "user.set_role", models.AuditEntityUser, userID, previous, user)
+	})
+	return user, err
+}
+
+func isRole(role models.Role) bool {
+	for _, known := range models.Roles {
+		if known == role {
+			return true
+		}
+	}
+	return false
+}
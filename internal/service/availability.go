@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// AddAvailabilityWindow registers an out-of-office period of a user. While the
+// window covers the current time the user is not picked as a reviewer.
+func (s *Service) AddAvailabilityWindow(ctx context.Context, window models.AvailabilityWindow) (*models.AvailabilityWindow, error) {
+	if window.UserID == "" {
+		return nil, newError(CodeInvalidRequest, "user_id is required")
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return nil, newError(CodeInvalidRequest, "ends_at must be after starts_at")
+	}
+	now := time.Now().UTC()
+	if !window.EndsAt.After(now) {
+		return nil, newError(CodeInvalidRequest, "ends_at must be in the future")
+	}
+
+	window.StartsAt = window.StartsAt.UTC()
+	window.EndsAt = window.EndsAt.UTC()
+	window.ReassignedAt = nil
+
+	err := s.update(ctx, func(tx storage.Tx) error {
+		user, err := tx.Users().Get(window.UserID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorizeAbsence(ctx, *user); err != nil {
+			return err
+		}
+
+		if err := tx.Availability().Create(&window); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "availability_window.create", models.AuditEntityAvailability,
+			strconv.FormatInt(window.ID, 10), nil, window)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A window that has already started is handled right away instead of
+	// waiting for the next watcher tick. The window is already stored, so a
+	// failure here is left to the watcher rather than reported to the client,
+	// whose retry would add a duplicate window. If the watcher gets to the
+	// window first, reassignAbsence does nothing.
+	if window.ReassignOpenReviews && window.Covers(now) {
+		_, processed, err := s.reassignAbsence(ctx, window, now)
+		if err != nil {
+			log.Printf("Failed to reassign reviews of absent user %s: %v", window.UserID, err)
+			return &window, nil
+		}
+		if processed {
+			window.ReassignedAt = &now
+		}
+	}
+
+	return &window, nil
+}
+
+// ListAvailabilityWindows returns out-of-office periods of a user
+func (s *Service) ListAvailabilityWindows(ctx context.Context, userID string) ([]models.AvailabilityWindow, error) {
+	var windows []models.AvailabilityWindow
+	err := s.view(ctx, func(tx storage.Tx) error {
+		_, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		windows, err = tx.Availability().ListByUser(userID)
+		return err
+	})
+	return windows, err
+}
+
+// DeleteAvailabilityWindow removes an out-of-office period of a user
+func (s *Service) DeleteAvailabilityWindow(ctx context.Context, userID string, id int64) error {
+	return s.update(ctx, func(tx storage.Tx) error {
+		user, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "availability window not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorizeAbsence(ctx, *user); err != nil {
+			return err
+		}
+
+		windows, err := tx.Availability().ListByUser(userID)
+		if err != nil {
+			return err
+		}
+		for _, window := range windows {
+			if window.ID == id {
+				if err := tx.Availability().Delete(userID, id); err != nil {
+					return err
+				}
+				return recordAudit(ctx, tx, "availability_window.delete", models.AuditEntityAvailability,
+					strconv.FormatInt(id, 10), window, nil)
+			}
+		}
+		return newError(CodeNotFound, "availability window not found")
+	})
+}
+
+// ReassignStartedAbsences reassigns pending reviews of open PRs of users whose
+// out-of-office window has started and asks for reassignment. Reviews the
+// user has already given a verdict on stay with them. Like SafeReassignOpenPRs
+// it skips PRs without a replacement candidate.
+// It returns the number of reassigned reviews.
+func (s *Service) ReassignStartedAbsences(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	var windows []models.AvailabilityWindow
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		windows, err = tx.Availability().PendingReassignments(now)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	reassignedCount := 0
+	for _, window := range windows {
+		count, _, err := s.reassignAbsence(ctx, window, now)
+		if err != nil {
+			return reassignedCount, err
+		}
+		reassignedCount += count
+	}
+
+	return reassignedCount, nil
+}
+
+// reassignAbsence reassigns pending reviews of the user of a started window
+// and marks the window as processed, in one transaction. The window is
+// claimed first, so when AddAvailabilityWindow and the watcher race for it
+// the later one finds it already processed (or deleted) and reports false.
+func (s *Service) reassignAbsence(ctx context.Context, window models.AvailabilityWindow, now time.Time) (int, bool, error) {
+	reassignedCount := 0
+	processed := false
+	err := s.update(ctx, func(tx storage.Tx) error {
+		reassignedCount = 0
+		processed = false
+
+		err := tx.Availability().MarkReassigned(window.ID, now)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		processed = true
+
+		prs, err := tx.PullRequests().ListByReviewer(window.UserID)
+		if err != nil {
+			return err
+		}
+		for _, short := range prs {
+			if short.Status != models.StatusOpen {
+				continue
+			}
+			pr, err := loadPullRequest(tx, short.PullRequestID)
+			if err != nil {
+				return err
+			}
+			if reviewStateOf(pr.AssignedReviewers, window.UserID) != models.ReviewPending {
+				continue
+			}
+
+			// Ignore service errors (NO_CANDIDATE, etc.) - the reviewer stays
+			// assigned and nothing has been written for this PR
+			_, _, err = s.reassignInTx(ctx, tx, pr.PullRequestID, window.UserID, models.ReasonOutOfOffice)
+			if _, ok := AsError(err); ok {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			reassignedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return reassignedCount, processed, nil
+}
+
+// WatchAvailability periodically reassigns open reviews of users whose
+// out-of-office window has started, until ctx is done
+func (s *Service) WatchAvailability(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.ReassignStartedAbsences(ctx)
+			if err != nil {
+				log.Printf("Failed to reassign reviews of absent users: %v", err)
+				continue
+			}
+			if count > 0 {
+				log.Printf("Reassigned %d reviews of absent users", count)
+			}
+		}
+	}
+}
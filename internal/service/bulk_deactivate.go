@@ -1,97 +1,114 @@
 package service
 
 import (
-	"database/sql"
-	"fmt"
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
 )
 
 // BulkDeactivateUsers deactivates multiple users in a team
-func (s *Service) BulkDeactivateUsers(teamName string, userIDs []string) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Check if team exists
-	var exists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("NOT_FOUND: team not found")
-	}
-
-	// Deactivate users
-	for _, userID := range userIDs {
-		// Verify user belongs to team
-		var userTeam string
-		err = tx.QueryRow("SELECT team_name FROM users WHERE user_id = $1", userID).Scan(&userTeam)
-		if err == sql.ErrNoRows {
-			continue // Skip non-existent users
+func (s *Service) BulkDeactivateUsers(ctx context.Context, teamName string, userIDs []string) error {
+	return s.update(ctx, func(tx storage.Tx) error {
+		// Check if team exists
+		_, err := tx.Teams().Get(teamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
 		}
 		if err != nil {
 			return err
 		}
-		if userTeam != teamName {
-			continue // Skip users not in the team
-		}
 
-		_, err = tx.Exec(`
-			UPDATE users
-			SET is_active = false, updated_at = CURRENT_TIMESTAMP
-			WHERE user_id = $1
-		`, userID)
-		if err != nil {
-			return err
+		// Deactivate users
+		for _, userID := range userIDs {
+			// Verify user belongs to team
+			user, err := tx.Users().Get(userID)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue // Skip non-existent users
+			}
+			if err != nil {
+				return err
+			}
+			if user.TeamName != teamName {
+				continue // Skip users not in the team
+			}
+
+			if err := tx.Users().SetActive(userID, false); err != nil {
+				return err
+			}
+			previous := *user
+			user.IsActive = false
+			if err := recordAudit(ctx, tx, "user.set_active", models.AuditEntityUser, userID, previous, user); err != nil {
+				return err
+			}
+			if previous.IsActive {
+				if err := emitEvent(tx, models.EventUserDeactivated, map[string]interface{}{"user": user}); err != nil {
+					return err
+				}
+			}
 		}
-	}
 
-	return tx.Commit()
+		return nil
+	})
 }
 
 // SafeReassignOpenPRs reassigns reviewers for open PRs when users are deactivated
-// This ensures open PRs always have active reviewers
-func (s *Service) SafeReassignOpenPRs(deactivatedUserIDs []string) (int, error) {
+// This ensures open PRs always have active reviewers. Reviews the user has
+// already given a verdict on stay with them.
+func (s *Service) SafeReassignOpenPRs(ctx context.Context, deactivatedUserIDs []string) (int, error) {
 	if len(deactivatedUserIDs) == 0 {
 		return 0, nil
 	}
 
-	// Build query to find open PRs with deactivated reviewers
-	query := `
-		SELECT DISTINCT pr.pull_request_id, prr.reviewer_id, u.team_name
-		FROM pull_requests pr
-		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		INNER JOIN users u ON prr.reviewer_id = u.user_id
-		WHERE pr.status = 'OPEN' AND prr.reviewer_id = ANY($1) AND u.is_active = false
-	`
-
-	rows, err := s.db.Query(query, deactivatedUserIDs)
-	if err != nil {
-		return 0, err
-	}
-	defer rows.Close()
-
 	type reassignInfo struct {
-		prID    string
-		oldID   string
-		teamName string
+		prID  string
+		oldID string
 	}
 
+	// Find open PRs with deactivated reviewers
 	var toReassign []reassignInfo
-	for rows.Next() {
-		var info reassignInfo
-		if err := rows.Scan(&info.prID, &info.oldID, &info.teamName); err != nil {
-			return 0, err
+	err := s.view(ctx, func(tx storage.Tx) error {
+		for _, userID := range deactivatedUserIDs {
+			user, err := tx.Users().Get(userID)
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if user.IsActive {
+				continue
+			}
+
+			prs, err := tx.PullRequests().ListByReviewer(userID)
+			if err != nil {
+				return err
+			}
+			for _, short := range prs {
+				if short.Status != models.StatusOpen {
+					continue
+				}
+				// Reviews with a verdict stay with the reviewer
+				pr, err := loadPullRequest(tx, short.PullRequestID)
+				if err != nil {
+					return err
+				}
+				if reviewStateOf(pr.AssignedReviewers, userID) == models.ReviewPending {
+					toReassign = append(toReassign, reassignInfo{prID: pr.PullRequestID, oldID: userID})
+				}
+			}
 		}
-		toReassign = append(toReassign, info)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	reassignedCount := 0
 	for _, info := range toReassign {
 		// Try to reassign - if it fails, we continue (no candidate available)
-		_, _, err := s.ReassignReviewer(info.prID, info.oldID)
+		_, _, err := s.reassignReviewer(ctx, info.prID, info.oldID, models.ReasonDeactivation)
 		if err == nil {
 			reassignedCount++
 		}
@@ -100,4 +117,3 @@ func (s *Service) SafeReassignOpenPRs(deactivatedUserIDs []string) (int, error)
 
 	return reassignedCount, nil
 }
-
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// teamOwnerPrefix marks team owners in a CODEOWNERS file: @team/<team_name>
+const teamOwnerPrefix = "team/"
+
+// codeOwnerLine is a parsed CODEOWNERS rule together with its line number
+type codeOwnerLine struct {
+	line int
+	rule models.CodeOwnerRule
+}
+
+// parseCodeOwners parses a CODEOWNERS file. Each non-empty line holds a path
+// pattern followed by owners: @<user_id> or @team/<team_name>. Everything
+// after # is a comment.
+func parseCodeOwners(content string) ([]codeOwnerLine, []models.CodeOwnersIssue) {
+	var parsed []codeOwnerLine
+	var issues []models.CodeOwnersIssue
+	for i, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		lineIssues := len(issues)
+		rule := models.CodeOwnerRule{Pattern: fields[0], Users: []string{}, Teams: []string{}}
+		if err := validateCodeOwnersPattern(rule.Pattern); err != nil {
+			issues = append(issues, models.CodeOwnersIssue{Line: i + 1, Message: err.Error()})
+		}
+		for _, owner := range fields[1:] {
+			name, ok := strings.CutPrefix(owner, "@")
+			if !ok || name == "" {
+				issues = append(issues, models.CodeOwnersIssue{Line: i + 1, Message: fmt.Sprintf("owner %q must be @<user_id> or @team/<team_name>", owner)})
+				continue
+			}
+			if team, ok := strings.CutPrefix(name, teamOwnerPrefix); ok {
+				rule.Teams = append(rule.Teams, team)
+			} else {
+				rule.Users = append(rule.Users, name)
+			}
+		}
+		if len(issues) == lineIssues {
+			parsed = append(parsed, codeOwnerLine{line: i + 1, rule: rule})
+		}
+	}
+	return parsed, issues
+}
+
+func validateCodeOwnersPattern(pattern string) error {
+	if strings.HasPrefix(pattern, "!") {
+		return fmt.Errorf("negated pattern %q is not supported", pattern)
+	}
+	if strings.Trim(pattern, "/") == "" {
+		return fmt.Errorf("pattern %q matches nothing", pattern)
+	}
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// matchCodeOwnersPattern reports whether a file path matches a CODEOWNERS
+// pattern. As in gitignore, a pattern without a slash (other than a trailing
+// one) matches at any depth, otherwise it is relative to the repository root;
+// * does not cross directories while ** does. A pattern naming a directory
+// matches everything inside it.
+func matchCodeOwnersPattern(pattern, filePath string) bool {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+	if !anchored {
+		pattern = "**/" + pattern
+	}
+
+	segments := strings.Split(pattern, "/")
+	dirMatch := !strings.Contains(segments[len(segments)-1], "*")
+	return matchSegments(segments, strings.Split(filePath, "/"), dirMatch)
+}
+
+func matchSegments(pattern, filePath []string, dirMatch bool) bool {
+	if len(pattern) == 0 {
+		return len(filePath) == 0 || dirMatch
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(filePath); i++ {
+			if matchSegments(pattern[1:], filePath[i:], dirMatch) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(filePath) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], filePath[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], filePath[1:], dirMatch)
+}
+
+// owningRules returns the rules owning at least one of the files, in file
+// order. As in CODEOWNERS, the last matching rule wins for each file.
+func owningRules(rules []models.CodeOwnerRule, files []string) []models.CodeOwnerRule {
+	owning := make(map[int]bool)
+	for _, file := range files {
+		for i := len(rules) - 1; i >= 0; i-- {
+			if matchCodeOwnersPattern(rules[i].Pattern, file) {
+				owning[i] = true
+				break
+			}
+		}
+	}
+
+	var matched []models.CodeOwnerRule
+	for i, rule := range rules {
+		if owning[i] {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// validateCodeOwners parses a CODEOWNERS file and checks that every owner exists
+func validateCodeOwners(tx storage.Tx, content string) (*models.CodeOwnersValidation, error) {
+	parsed, issues := parseCodeOwners(content)
+
+	rules := make([]models.CodeOwnerRule, 0, len(parsed))
+	for _, p := range parsed {
+		for _, userID := range p.rule.Users {
+			if _, err := tx.Users().Get(userID); errors.Is(err, storage.ErrNotFound) {
+				issues = append(issues, models.CodeOwnersIssue{Line: p.line, Message: fmt.Sprintf("user %s not found", userID)})
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		for _, teamName := range p.rule.Teams {
+			if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+				issues = append(issues, models.CodeOwnersIssue{Line: p.line, Message: fmt.Sprintf("team %s not found", teamName)})
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		rules = append(rules, p.rule)
+	}
+
+	if issues == nil {
+		issues = []models.CodeOwnersIssue{}
+	}
+	return &models.CodeOwnersValidation{
+		Valid:  len(issues) == 0,
+		Rules:  rules,
+		Errors: issues,
+	}, nil
+}
+
+// ValidateCodeOwners checks a CODEOWNERS file without storing it
+func (s *Service) ValidateCodeOwners(ctx context.Context, content string) (*models.CodeOwnersValidation, error) {
+	var validation *models.CodeOwnersValidation
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		validation, err = validateCodeOwners(tx, content)
+		return err
+	})
+	return validation, err
+}
+
+// UploadCodeOwners validates a CODEOWNERS file and replaces the stored
+// ownership rules with it. Nothing is stored if the file has errors.
+func (s *Service) UploadCodeOwners(ctx context.Context, content string) ([]models.CodeOwnerRule, error) {
+	var rules []models.CodeOwnerRule
+	err := s.update(ctx, func(tx storage.Tx) error {
+		validation, err := validateCodeOwners(tx, content)
+		if err != nil {
+			return err
+		}
+		if !validation.Valid {
+			return newError(CodeInvalidCodeOwners, "CODEOWNERS file has %d error(s)", len(validation.Errors)).
+				withDetail("errors", validation.Errors)
+		}
+
+		rules = validation.Rules
+		return tx.CodeOwners().Replace(rules)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetCodeOwners returns the stored ownership rules in file order
+func (s *Service) GetCodeOwners(ctx context.Context) ([]models.CodeOwnerRule, error) {
+	var rules []models.CodeOwnerRule
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		rules, err = tx.CodeOwners().List()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []models.CodeOwnerRule{}
+	}
+	return rules, nil
+}
+
+// selectCodeOwners picks one reviewer for every rule owning the changed files,
+// unless an owner of the rule has already been picked. Owners must be
+// eligible candidates of their team and within capacity; the least loaded one
+// is chosen. Rules without such owners are skipped.
+func selectCodeOwners(tx storage.Tx, changedFiles, excludeIDs []string) ([]models.Reviewer, error) {
+	if len(changedFiles) == 0 {
+		return nil, nil
+	}
+	rules, err := tx.CodeOwners().List()
+	if err != nil {
+		return nil, err
+	}
+
+	teamCandidates := make(map[string][]Candidate)
+	candidatesOf := func(teamName string) ([]Candidate, error) {
+		if candidates, ok := teamCandidates[teamName]; ok {
+			return candidates, nil
+		}
+		candidates, err := queryCandidates(tx, teamName, excludeIDs)
+		if err != nil {
+			return nil, err
+		}
+		teamCandidates[teamName] = candidates
+		return candidates, nil
+	}
+
+	var selected []models.Reviewer
+	picked := make(map[string]bool)
+	for _, rule := range owningRules(rules, changedFiles) {
+		owners, err := ruleOwners(tx, rule, candidatesOf)
+		if err != nil {
+			return nil, err
+		}
+
+		covered := false
+		for _, owner := range owners {
+			covered = covered || picked[owner.UserID]
+		}
+		if covered {
+			continue
+		}
+
+		ids := LeastLoadedSelector{}.Select(SelectionRequest{
+			Candidates: withinCapacity(owners),
+			Count:      1,
+		})
+		if len(ids) == 0 {
+			continue
+		}
+		picked[ids[0]] = true
+		selected = append(selected, models.Reviewer{UserID: ids[0]})
+	}
+	return selected, nil
+}
+
+// ruleOwners returns the candidates among the users and team members owning a rule
+func ruleOwners(tx storage.Tx, rule models.CodeOwnerRule, candidatesOf func(teamName string) ([]Candidate, error)) ([]Candidate, error) {
+	var owners []Candidate
+	seen := make(map[string]bool)
+	add := func(candidate Candidate) {
+		if !seen[candidate.UserID] {
+			seen[candidate.UserID] = true
+			owners = append(owners, candidate)
+		}
+	}
+
+	for _, userID := range rule.Users {
+		user, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.TeamName == "" {
+			continue // Users removed from their team are not candidates
+		}
+		candidates, err := candidatesOf(user.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			if candidate.UserID == userID {
+				add(candidate)
+			}
+		}
+	}
+	for _, teamName := range rule.Teams {
+		candidates, err := candidatesOf(teamName)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			add(candidate)
+		}
+	}
+	return owners, nil
+}
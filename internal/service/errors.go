@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error codes returned by the service
+const (
+	CodeInvalidRequest     = "INVALID_REQUEST"
+	CodeNotFound           = "NOT_FOUND"
+	CodeTeamExists         = "TEAM_EXISTS"
+	CodeUserInTeam         = "USER_IN_TEAM"
+	CodeNoTeam             = "NO_TEAM"
+	CodeHasOpenPRs         = "HAS_OPEN_PRS"
+	CodePRExists           = "PR_EXISTS"
+	CodePRMerged           = "PR_MERGED"
+	CodePRNotOpen          = "PR_NOT_OPEN"
+	CodeNotAssigned        = "NOT_ASSIGNED"
+	CodeNoCandidate        = "NO_CANDIDATE"
+	CodeNotEnoughReviewers = "NOT_ENOUGH_REVIEWERS"
+	CodeMergeBlocked       = "MERGE_BLOCKED"
+	CodeInvalidTransition  = "INVALID_TRANSITION"
+	CodeInvalidCodeOwners  = "INVALID_CODEOWNERS"
+	CodeInvalidSignature   = "INVALID_SIGNATURE"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeTimeout            = "TIMEOUT"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+// codeHTTPStatus maps error codes to the HTTP status they are reported with
+var codeHTTPStatus = map[string]int{
+	CodeInvalidRequest:     http.StatusBadRequest,
+	CodeNotFound:           http.StatusNotFound,
+	CodeTeamExists:         http.StatusBadRequest,
+	CodeUserInTeam:         http.StatusConflict,
+	CodeNoTeam:             http.StatusConflict,
+	CodeHasOpenPRs:         http.StatusConflict,
+	CodePRExists:           http.StatusConflict,
+	CodePRMerged:           http.StatusConflict,
+	CodePRNotOpen:          http.StatusConflict,
+	CodeNotAssigned:        http.StatusConflict,
+	CodeNoCandidate:        http.StatusConflict,
+	CodeNotEnoughReviewers: http.StatusConflict,
+	CodeMergeBlocked:       http.StatusConflict,
+	CodeInvalidTransition:  http.StatusConflict,
+	CodeInvalidCodeOwners:  http.StatusBadRequest,
+	CodeInvalidSignature:   http.StatusUnauthorized,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeTimeout:            http.StatusGatewayTimeout,
+	CodeInternal:           http.StatusInternalServerError,
+}
+
+// Error is a domain error with a stable code, a human-readable message,
+// an HTTP status hint and optional structured details
+type Error struct {
+	Code       string
+	Message    string
+	HTTPStatus int
+	Details    map[string]interface{}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// newError creates a domain error with the HTTP status registered for the code
+func newError(code, format string, args ...interface{}) *Error {
+	status, ok := codeHTTPStatus[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	return &Error{
+		Code:       code,
+		Message:    fmt.Sprintf(format, args...),
+		HTTPStatus: status,
+	}
+}
+
+// withDetail attaches a structured detail to the error
+func (e *Error) withDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// AsError extracts a domain error from an error chain
+func AsError(err error) (*Error, bool) {
+	var svcErr *Error
+	if errors.As(err, &svcErr) {
+		return svcErr, true
+	}
+	return nil, false
+}
+
+// IsErrorCode reports whether err is a domain error with the given code
+func IsErrorCode(err error, code string) bool {
+	svcErr, ok := AsError(err)
+	return ok && svcErr.Code == code
+}
+
+// contextError reports a failure caused by an expired request deadline as a
+// TIMEOUT error. The driver may surface it as its own cancellation error, so
+// the context itself is checked rather than err.
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := AsError(err); ok {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newError(CodeTimeout, "request deadline exceeded")
+	}
+	return err
+}
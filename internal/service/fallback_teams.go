@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// GetFallbackTeams returns the fallback teams of a team in priority order
+func (s *Service) GetFallbackTeams(ctx context.Context, teamName string) (*models.TeamFallbacks, error) {
+	var fallbacks *models.TeamFallbacks
+	err := s.view(ctx, func(tx storage.Tx) error {
+		teams, err := tx.Teams().FallbackTeams(teamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		fallbacks = &models.TeamFallbacks{TeamName: teamName, FallbackTeams: nonNilStrings(teams)}
+		return nil
+	})
+	return fallbacks, err
+}
+
+// SetFallbackTeams replaces the fallback teams of a team. Teams are tried in
+// the given order once the home team has no suitable reviewers left.
+func (s *Service) SetFallbackTeams(ctx context.Context, fallbacks models.TeamFallbacks) (*models.TeamFallbacks, error) {
+	seen := make(map[string]bool, len(fallbacks.FallbackTeams))
+	for _, teamName := range fallbacks.FallbackTeams {
+		if teamName == fallbacks.TeamName {
+			return nil, newError(CodeInvalidRequest, "team cannot be its own fallback team")
+		}
+		if seen[teamName] {
+			return nil, newError(CodeInvalidRequest, "fallback team %s is listed twice", teamName)
+		}
+		seen[teamName] = true
+	}
+
+	err := s.update(ctx, func(tx storage.Tx) error {
+		previous, err := tx.Teams().FallbackTeams(fallbacks.TeamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, fallbacks.TeamName); err != nil {
+			return err
+		}
+		for _, teamName := range fallbacks.FallbackTeams {
+			if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+				return newError(CodeNotFound, "fallback team %s not found", teamName).
+					withDetail("fallback_team", teamName)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Teams().SetFallbackTeams(fallbacks.TeamName, fallbacks.FallbackTeams); err != nil {
+			return err
+		}
+		before := models.TeamFallbacks{TeamName: fallbacks.TeamName, FallbackTeams: nonNilStrings(previous)}
+		after := models.TeamFallbacks{TeamName: fallbacks.TeamName, FallbackTeams: nonNilStrings(fallbacks.FallbackTeams)}
+		return recordAudit(ctx, tx, "team.set_fallbacks", models.AuditEntityTeam, fallbacks.TeamName, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fallbacks.FallbackTeams = nonNilStrings(fallbacks.FallbackTeams)
+	return &fallbacks, nil
+}
+
+// candidatePool holds the reviewer candidates supplied by a single team
+type candidatePool struct {
+	teamName   string
+	fallback   bool
+	candidates []Candidate
+}
+
+// queryCandidatePools returns the candidates of the home team followed by
+// those of its fallback teams in priority order. Fallbacks are not transitive:
+// fallback teams of a fallback team are not consulted.
+func queryCandidatePools(tx storage.Tx, homeTeam string, excludeIDs []string) ([]candidatePool, error) {
+	fallbackTeams, err := tx.Teams().FallbackTeams(homeTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]candidatePool, 0, len(fallbackTeams)+1)
+	for i, teamName := range append([]string{homeTeam}, fallbackTeams...) {
+		candidates, err := queryCandidates(tx, teamName, excludeIDs)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, candidatePool{
+			teamName:   teamName,
+			fallback:   i > 0,
+			candidates: candidates,
+		})
+	}
+	return pools, nil
+}
+
+// countCandidates returns the number of candidates in all pools, including
+// those at capacity
+func countCandidates(pools []candidatePool) int {
+	total := 0
+	for _, pool := range pools {
+		total += len(pool.candidates)
+	}
+	return total
+}
+
+// selectFromPools picks up to n reviewers within capacity, exhausting each
+// pool before moving on to the next one. Every team applies its own strategy;
+// the returned strategy is the one of the home team, even if n is 0.
+// Reviewers taken from a fallback team are marked with its name.
+func (s *Service) selectFromPools(tx storage.Tx, pools []candidatePool, n int) ([]models.Reviewer, models.SelectionStrategy, error) {
+	var selected []models.Reviewer
+	var homeStrategy models.SelectionStrategy
+	for _, pool := range pools {
+		if pool.fallback && len(selected) >= n {
+			break
+		}
+
+		ids, strategy, err := s.selectReviewers(tx, pool.teamName, withinCapacity(pool.candidates), n-len(selected))
+		if err != nil {
+			return nil, "", err
+		}
+		if !pool.fallback {
+			homeStrategy = strategy
+		}
+
+		for _, id := range ids {
+			reviewer := models.Reviewer{UserID: id}
+			if pool.fallback {
+				reviewer.FallbackTeam = pool.teamName
+			}
+			selected = append(selected, reviewer)
+		}
+	}
+	return selected, homeStrategy, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// recordAssignment appends an event to the assignment history in the
+// transaction of the change
+func recordAssignment(ctx context.Context, tx storage.Tx, event models.AssignmentEvent) error {
+	event.Actor = actorFrom(ctx)
+	event.OccurredAt = time.Now().UTC()
+	return tx.Assignments().Append(&event)
+}
+
+// GetAssignmentHistory returns every reviewer assignment, reassignment and
+// removal of a pull request, oldest first
+func (s *Service) GetAssignmentHistory(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	var history []models.AssignmentEvent
+	err := s.view(ctx, func(tx storage.Tx) error {
+		if _, err := tx.PullRequests().Get(prID); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "PR not found")
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		history, err = tx.Assignments().ListByPullRequest(prID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []models.AssignmentEvent{}
+	}
+	return history, nil
+}
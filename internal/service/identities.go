@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// normalizeLogin returns the form logins are stored in. GitHub and GitLab
+// logins are case-insensitive.
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+func validateProvider(provider models.IdentityProvider) error {
+	if provider != models.ProviderGitHub && provider != models.ProviderGitLab {
+		return newError(CodeInvalidRequest, "provider must be %s or %s", models.ProviderGitHub, models.ProviderGitLab)
+	}
+	return nil
+}
+
+// ListIdentities returns all external identities ordered by provider and login
+func (s *Service) ListIdentities(ctx context.Context) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		identities, err = tx.Identities().List()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if identities == nil {
+		identities = []models.UserIdentity{}
+	}
+	return identities, nil
+}
+
+// SetIdentity maps a login on an external provider to a user, replacing any
+// previous mapping of the login
+func (s *Service) SetIdentity(ctx context.Context, identity models.UserIdentity) (*models.UserIdentity, error) {
+	if err := validateProvider(identity.Provider); err != nil {
+		return nil, err
+	}
+	identity.Login = normalizeLogin(identity.Login)
+	if identity.Login == "" {
+		return nil, newError(CodeInvalidRequest, "login is required")
+	}
+
+	err := s.update(ctx, func(tx storage.Tx) error {
+		if _, err := tx.Users().Get(identity.UserID); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		} else if err != nil {
+			return err
+		}
+
+		var before interface{}
+		previous, err := tx.Identities().Get(identity.Provider, identity.Login)
+		if err == nil {
+			before = previous
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		if err := tx.Identities().Upsert(identity); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "identity.set", models.AuditEntityIdentity, identityID(identity.Provider, identity.Login), before, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// DeleteIdentity removes the mapping of an external login
+func (s *Service) DeleteIdentity(ctx context.Context, provider models.IdentityProvider, login string) error {
+	if err := validateProvider(provider); err != nil {
+		return err
+	}
+	login = normalizeLogin(login)
+	return s.update(ctx, func(tx storage.Tx) error {
+		identity, err := tx.Identities().Get(provider, login)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "identity not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Identities().Delete(provider, login); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "identity.delete", models.AuditEntityIdentity, identityID(provider, login), identity, nil)
+	})
+}
+
+// identityID identifies an external login in the audit log
+func identityID(provider models.IdentityProvider, login string) string {
+	return string(provider) + ":" + login
+}
+
+// resolveIdentity returns the user behind an external login
+func resolveIdentity(tx storage.Tx, provider models.IdentityProvider, login string) (string, error) {
+	identity, err := tx.Identities().Get(provider, normalizeLogin(login))
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", newError(CodeNotFound, "no user is mapped to %s login %s", provider, login).
+			withDetail("provider", provider).
+			withDetail("login", login)
+	}
+	if err != nil {
+		return "", err
+	}
+	return identity.UserID, nil
+}
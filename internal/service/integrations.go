@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Headers of inbound webhook requests
+const (
+	GitHubEventHeader     = "X-GitHub-Event"
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+	GitLabEventHeader     = "X-Gitlab-Event"
+	GitLabTokenHeader     = "X-Gitlab-Token"
+)
+
+// Actions reported in models.IntegrationResult
+const (
+	IntegrationCreated  = "created"
+	IntegrationReady    = "ready"
+	IntegrationMerged   = "merged"
+	IntegrationClosed   = "closed"
+	IntegrationReopened = "reopened"
+	IntegrationIgnored  = "ignored"
+)
+
+// SetGitHubWebhookSecret sets the secret GitHub signs webhooks with. The
+// GitHub integration is disabled while the secret is empty.
+func (s *Service) SetGitHubWebhookSecret(secret string) {
+	s.githubSecret = secret
+}
+
+// SetGitLabWebhookToken sets the token GitLab sends with webhooks. The GitLab
+// integration is disabled while the token is empty.
+func (s *Service) SetGitLabWebhookToken(token string) {
+	s.gitlabToken = token
+}
+
+// githubPullRequestEvent is the part of a GitHub pull_request event payload
+// the service uses
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// gitlabMergeRequestEvent is the part of a GitLab merge request event payload
+// the service uses
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// HandleGitHubWebhook applies a GitHub webhook to the matching PR. The
+// signature is the X-Hub-Signature-256 header. Only pull_request events are
+// handled: opened creates the PR, ready_for_review, reopened and closed move
+// it through its lifecycle. PRs are identified as github:<repo>#<number> and
+// authors are resolved through the identity table. Changes are audited as
+// made by github:<sender login>.
+func (s *Service) HandleGitHubWebhook(ctx context.Context, event, signature string, body []byte) (*models.IntegrationResult, error) {
+	if s.githubSecret == "" {
+		return nil, newError(CodeNotFound, "GitHub integration is not configured")
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignWebhookPayload(s.githubSecret, body))) {
+		return nil, newError(CodeInvalidSignature, "invalid %s header", GitHubSignatureHeader)
+	}
+	if event != "pull_request" {
+		return &models.IntegrationResult{Action: IntegrationIgnored}, nil
+	}
+
+	var payload githubPullRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, newError(CodeInvalidRequest, "invalid pull_request payload")
+	}
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, newError(CodeInvalidRequest, "pull_request payload has no repository or number")
+	}
+	prID := fmt.Sprintf("github:%s#%d", payload.Repository.FullName, payload.PullRequest.Number)
+	ctx = WithActor(ctx, fmt.Sprintf("%s:%s", models.ProviderGitHub, payload.Sender.Login))
+
+	switch payload.Action {
+	case "opened":
+		return s.createFromWebhook(ctx, models.ProviderGitHub, payload.PullRequest.User.Login, prID, payload.PullRequest.Title, payload.PullRequest.Draft)
+	case "ready_for_review":
+		return integrationResult(IntegrationReady)(s.MarkPullRequestReady(ctx, prID))
+	case "reopened":
+		return integrationResult(IntegrationReopened)(s.ReopenPullRequest(ctx, prID))
+	case "closed":
+		if payload.PullRequest.Merged {
+			return s.mergeFromWebhook(ctx, prID)
+		}
+		return integrationResult(IntegrationClosed)(s.ClosePullRequest(ctx, prID))
+	}
+	return &models.IntegrationResult{Action: IntegrationIgnored}, nil
+}
+
+// HandleGitLabWebhook applies a GitLab webhook to the matching PR. The token
+// is the X-Gitlab-Token header. Only merge request events are handled: open
+// creates the PR, reopen, close, merge and an update removing the draft flag
+// move it through its lifecycle. PRs are identified as gitlab:<project>!<iid>
+// and authors are resolved through the identity table. Changes are audited as
+// made by gitlab:<username>.
+func (s *Service) HandleGitLabWebhook(ctx context.Context, event, token string, body []byte) (*models.IntegrationResult, error) {
+	if s.gitlabToken == "" {
+		return nil, newError(CodeNotFound, "GitLab integration is not configured")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.gitlabToken)) != 1 {
+		return nil, newError(CodeInvalidSignature, "invalid %s header", GitLabTokenHeader)
+	}
+	if event != "Merge Request Hook" {
+		return &models.IntegrationResult{Action: IntegrationIgnored}, nil
+	}
+
+	var payload gitlabMergeRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ObjectKind != "merge_request" {
+		return nil, newError(CodeInvalidRequest, "invalid merge_request payload")
+	}
+	attrs := payload.ObjectAttributes
+	if payload.Project.PathWithNamespace == "" || attrs.IID == 0 {
+		return nil, newError(CodeInvalidRequest, "merge_request payload has no project or iid")
+	}
+	prID := fmt.Sprintf("gitlab:%s!%d", payload.Project.PathWithNamespace, attrs.IID)
+	ctx = WithActor(ctx, fmt.Sprintf("%s:%s", models.ProviderGitLab, payload.User.Username))
+
+	switch attrs.Action {
+	case "open":
+		// The user of an open event is the author of the merge request
+		return s.createFromWebhook(ctx, models.ProviderGitLab, payload.User.Username, prID, attrs.Title, attrs.Draft || attrs.WorkInProgress)
+	case "update":
+		if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+			return integrationResult(IntegrationReady)(s.MarkPullRequestReady(ctx, prID))
+		}
+	case "reopen":
+		return integrationResult(IntegrationReopened)(s.ReopenPullRequest(ctx, prID))
+	case "close":
+		return integrationResult(IntegrationClosed)(s.ClosePullRequest(ctx, prID))
+	case "merge":
+		return s.mergeFromWebhook(ctx, prID)
+	}
+	return &models.IntegrationResult{Action: IntegrationIgnored}, nil
+}
+
+// createFromWebhook creates a PR opened on a provider by the given login
+func (s *Service) createFromWebhook(ctx context.Context, provider models.IdentityProvider, login, prID, title string, draft bool) (*models.IntegrationResult, error) {
+	var authorID string
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		authorID, err = resolveIdentity(tx, provider, login)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return integrationResult(IntegrationCreated)(s.CreatePullRequest(ctx, prID, title, authorID, CreatePullRequestOptions{Draft: draft}))
+}
+
+// mergeFromWebhook records a merge that already happened on the provider.
+// The merge policy cannot stop it, so it is forced; the bypass is still
+// recorded on the PR if the policy was not satisfied.
+func (s *Service) mergeFromWebhook(ctx context.Context, prID string) (*models.IntegrationResult, error) {
+	return integrationResult(IntegrationMerged)(s.MergePullRequest(ctx, prID, MergePullRequestOptions{Force: true}))
+}
+
+// integrationResult wraps the outcome of a PR operation into a result
+func integrationResult(action string) func(pr *models.PullRequest, err error) (*models.IntegrationResult, error) {
+	return func(pr *models.PullRequest, err error) (*models.IntegrationResult, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &models.IntegrationResult{Action: action, PullRequest: pr}, nil
+	}
+}
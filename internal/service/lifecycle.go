@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// prAction is an operation that moves a PR to another status
+type prAction string
+
+const (
+	actionReady  prAction = "ready"
+	actionReopen prAction = "reopen"
+	actionClose  prAction = "close"
+	actionMerge  prAction = "merge"
+)
+
+// prTransition describes the statuses an action may be applied to and the
+// status it results in
+type prTransition struct {
+	from []models.PullRequestStatus
+	to   models.PullRequestStatus
+}
+
+// transitions is the PR state machine. MERGED is terminal.
+var transitions = map[prAction]prTransition{
+	actionReady:  {from: []models.PullRequestStatus{models.StatusDraft}, to: models.StatusOpen},
+	actionReopen: {from: []models.PullRequestStatus{models.StatusClosed}, to: models.StatusOpen},
+	actionClose:  {from: []models.PullRequestStatus{models.StatusDraft, models.StatusOpen}, to: models.StatusClosed},
+	actionMerge:  {from: []models.PullRequestStatus{models.StatusOpen}, to: models.StatusMerged},
+}
+
+// checkTransition returns an INVALID_TRANSITION error if the action cannot be
+// applied to a PR in the given status
+func checkTransition(action prAction, current models.PullRequestStatus) error {
+	transition := transitions[action]
+	for _, from := range transition.from {
+		if from == current {
+			return nil
+		}
+	}
+	return newError(CodeInvalidTransition, "cannot %s PR in %s status", action, current).
+		withDetail("action", action).
+		withDetail("status", current)
+}
+
+// ClosePullRequest abandons a DRAFT or OPEN PR (idempotent)
+func (s *Service) ClosePullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.transitionPullRequest(ctx, prID, actionClose, func(tx storage.Tx, pr *models.PullRequest, now time.Time) error {
+		pr.Status = models.StatusClosed
+		pr.ClosedAt = &now
+		return tx.PullRequests().Update(*pr)
+	})
+}
+
+// ReopenPullRequest moves a CLOSED PR back to OPEN keeping its reviewers (idempotent)
+func (s *Service) ReopenPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.transitionPullRequest(ctx, prID, actionReopen, func(tx storage.Tx, pr *models.PullRequest, now time.Time) error {
+		pr.Status = models.StatusOpen
+		pr.ClosedAt = nil
+		return tx.PullRequests().Update(*pr)
+	})
+}
+
+// MarkPullRequestReady moves a DRAFT PR to OPEN and assigns its reviewers (idempotent)
+func (s *Service) MarkPullRequestReady(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.transitionPullRequest(ctx, prID, actionReady, func(tx storage.Tx, pr *models.PullRequest, now time.Time) error {
+		author, err := tx.Users().Get(pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		teamName, err := requireTeam(author)
+		if err != nil {
+			return err
+		}
+
+		pr.Status = models.StatusOpen
+		if err := tx.PullRequests().Update(*pr); err != nil {
+			return err
+		}
+
+		return s.assignInitialReviewers(ctx, tx, pr, teamName)
+	})
+}
+
+// transitionPullRequest applies an action to a PR inside a transaction.
+// A PR already in the target status is returned unchanged.
+func (s *Service) transitionPullRequest(ctx context.Context, prID string, action prAction, apply func(tx storage.Tx, pr *models.PullRequest, now time.Time) error) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.update(ctx, func(tx storage.Tx) error {
+		current, err := tx.PullRequests().Get(prID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "PR not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.Status == transitions[action].to {
+			pr, err = loadPullRequest(tx, prID)
+			return err
+		}
+		if err := checkTransition(action, current.Status); err != nil {
+			return err
+		}
+		before, err := loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
+		if err := apply(tx, current, time.Now()); err != nil {
+			return err
+		}
+
+		pr, err = loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "pull_request."+string(action), models.AuditEntityPullRequest, prID, before, pr)
+	})
+	return pr, err
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Listing page sizes
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// ListTeams returns a page of teams ordered by name with their member counts.
+// The cursor is the NextCursor of the previous page or empty for the first one.
+func (s *Service) ListTeams(ctx context.Context, filter models.TeamFilter, cursor string) (*models.TeamPage, error) {
+	limit, err := pageLimit(filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if filter.After, err = decodeKeyCursor(cursor); err != nil {
+		return nil, err
+	}
+
+	// One extra team tells whether there is a next page
+	filter.Limit = limit + 1
+	var teams []models.TeamSummary
+	err = s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		teams, err = tx.Teams().List(filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TeamPage{Teams: teams}
+	if len(teams) > limit {
+		page.Teams = teams[:limit]
+		page.NextCursor = encodeKeyCursor(page.Teams[limit-1].TeamName)
+	}
+	if page.Teams == nil {
+		page.Teams = []models.TeamSummary{}
+	}
+	return page, nil
+}
+
+// ListUsers returns a page of users ordered by user_id. The cursor is the
+// NextCursor of the previous page or empty for the first one.
+func (s *Service) ListUsers(ctx context.Context, filter models.UserFilter, cursor string) (*models.UserPage, error) {
+	limit, err := pageLimit(filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if filter.After, err = decodeKeyCursor(cursor); err != nil {
+		return nil, err
+	}
+
+	// One extra user tells whether there is a next page
+	filter.Limit = limit + 1
+	var users []models.User
+	err = s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		users, err = tx.Users().List(filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.UserPage{Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = encodeKeyCursor(page.Users[limit-1].UserID)
+	}
+	if page.Users == nil {
+		page.Users = []models.User{}
+	}
+	return page, nil
+}
+
+// ListPullRequests returns a page of the pull requests matching the filter.
+// The cursor is the NextCursor of the previous page or empty for the first one.
+func (s *Service) ListPullRequests(ctx context.Context, filter models.PullRequestFilter, cursor string) (*models.PullRequestPage, error) {
+	var page *models.PullRequestPage
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		page, err = s.listPullRequests(tx, filter, cursor)
+		return err
+	})
+	return page, err
+}
+
+// listPullRequests validates a filter and returns one page of the pull
+// requests it selects. The cursor is the NextCursor of the previous page or
+// empty for the first one.
+func (s *Service) listPullRequests(tx storage.Tx, filter models.PullRequestFilter, cursor string) (*models.PullRequestPage, error) {
+	if err := validatePullRequestFilter(&filter); err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		key, err := decodePullRequestCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = key
+	}
+
+	// One extra PR tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	prs, err := tx.PullRequests().List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.PullRequestPage{PullRequests: prs}
+	if len(prs) > limit {
+		page.PullRequests = prs[:limit]
+		page.NextCursor = encodePullRequestCursor(page.PullRequests[limit-1])
+	}
+	if page.PullRequests == nil {
+		page.PullRequests = []models.PullRequestShort{}
+	}
+	return page, nil
+}
+
+// validatePullRequestFilter checks a filter and fills in the default limit
+// and sort order
+func validatePullRequestFilter(filter *models.PullRequestFilter) error {
+	var err error
+	if filter.Limit, err = pageLimit(filter.Limit); err != nil {
+		return err
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = models.SortNewestFirst
+	case models.SortNewestFirst, models.SortOldestFirst:
+	default:
+		return newError(CodeInvalidRequest, "sort must be created_at or -created_at")
+	}
+	for _, status := range filter.Statuses {
+		switch status {
+		case models.StatusDraft, models.StatusOpen, models.StatusMerged, models.StatusClosed:
+		default:
+			return newError(CodeInvalidRequest, "unknown status %q", status)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return newError(CodeInvalidRequest, "from must be before to")
+	}
+	return nil
+}
+
+// pageLimit returns the page size for a requested limit, 0 meaning the default
+func pageLimit(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return defaultListLimit, nil
+	case limit < 0 || limit > maxListLimit:
+		return 0, newError(CodeInvalidRequest, "limit must be between 1 and %d", maxListLimit)
+	}
+	return limit, nil
+}
+
+// encodeKeyCursor returns an opaque pagination cursor pointing at a team
+// name or user id
+func encodeKeyCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeKeyCursor returns the key of a cursor; an empty cursor gives an
+// empty key
+func decodeKeyCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || (cursor != "" && len(raw) == 0) {
+		return "", newError(CodeInvalidRequest, "invalid cursor")
+	}
+	return string(raw), nil
+}
+
+// encodePullRequestCursor returns an opaque pagination cursor pointing at a
+// pull request
+func encodePullRequestCursor(pr models.PullRequestShort) string {
+	var createdAt time.Time
+	if pr.CreatedAt != nil {
+		createdAt = *pr.CreatedAt
+	}
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + pr.PullRequestID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePullRequestCursor(cursor string) (*models.PullRequestKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, newError(CodeInvalidRequest, "invalid cursor")
+	}
+	createdAt, prID, ok := strings.Cut(string(raw), "|")
+	if !ok || prID == "" {
+		return nil, newError(CodeInvalidRequest, "invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, newError(CodeInvalidRequest, "invalid cursor")
+	}
+	return &models.PullRequestKey{CreatedAt: t, PullRequestID: prID}, nil
+}
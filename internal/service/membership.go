@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// AddTeamMembers adds users to an existing team. Unknown users are created
+// and members of the team are updated; users who belong to another team are
+// refused, they are moved with MoveTeamMember.
+func (s *Service) AddTeamMembers(ctx context.Context, teamName string, members []models.TeamMember) error {
+	if len(members) == 0 {
+		return newError(CodeInvalidRequest, "members are required")
+	}
+	if err := validateTeamMembers(members); err != nil {
+		return err
+	}
+
+	return s.update(ctx, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		} else if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, teamName); err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			if err := addTeamMember(ctx, tx, teamName, member); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTeamMember takes a user out of their team. The user is kept, with
+// their PRs and history, but is no longer picked as a reviewer. Users who
+// author DRAFT or OPEN PRs cannot be removed, only moved to another team.
+// The policy decides what happens to their pending reviews of OPEN PRs:
+// keep (the default) or reassign_old_team.
+func (s *Service) RemoveTeamMember(ctx context.Context, teamName, userID string, policy models.ReviewPolicy) (*models.MembershipChange, error) {
+	switch policy {
+	case "", models.ReviewPolicyKeep, models.ReviewPolicyReassignOldTeam:
+	default:
+		return nil, newError(CodeInvalidRequest, "review_policy must be keep or reassign_old_team")
+	}
+
+	var change *models.MembershipChange
+	err := s.update(ctx, func(tx storage.Tx) error {
+		user, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if user.TeamName != teamName {
+			return newError(CodeNotFound, "user is not a member of team %s", teamName)
+		}
+		if err := authorizeTeam(ctx, teamName); err != nil {
+			return err
+		}
+
+		// PRs of an author without a team have nobody to review or merge them
+		authored, err := tx.PullRequests().List(models.PullRequestFilter{
+			AuthorID: userID,
+			Statuses: []models.PullRequestStatus{models.StatusDraft, models.StatusOpen},
+			Limit:    1,
+		})
+		if err != nil {
+			return err
+		}
+		if len(authored) > 0 {
+			return newError(CodeHasOpenPRs, "user authors open PRs; move them to another team instead").
+				withDetail("pull_request_id", authored[0].PullRequestID)
+		}
+
+		change, err = s.changeTeam(ctx, tx, user, "", policy)
+		return err
+	})
+	return change, err
+}
+
+// MoveTeamMember moves a user to another team. The policy decides what
+// happens to their pending reviews of OPEN PRs: keep (the default),
+// reassign_new_team or reassign_old_team. Moving a user to the team they are
+// in changes nothing.
+func (s *Service) MoveTeamMember(ctx context.Context, userID, teamName string, policy models.ReviewPolicy) (*models.MembershipChange, error) {
+	switch policy {
+	case "", models.ReviewPolicyKeep, models.ReviewPolicyReassignNewTeam, models.ReviewPolicyReassignOldTeam:
+	default:
+		return nil, newError(CodeInvalidRequest, "review_policy must be keep, reassign_new_team or reassign_old_team")
+	}
+
+	var change *models.MembershipChange
+	err := s.update(ctx, func(tx storage.Tx) error {
+		user, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		} else if err != nil {
+			return err
+		}
+		// Moving changes both teams
+		if user.TeamName != "" {
+			if err := authorizeTeam(ctx, user.TeamName); err != nil {
+				return err
+			}
+		}
+		if err := authorizeTeam(ctx, teamName); err != nil {
+			return err
+		}
+
+		if user.TeamName == teamName {
+			change = &models.MembershipChange{User: *user, Reassigned: []models.ReviewerReplacement{}}
+			return nil
+		}
+		if user.TeamName == "" && policy == models.ReviewPolicyReassignOldTeam {
+			return newError(CodeNoTeam, "user %s has no team to reassign their reviews within", userID)
+		}
+
+		change, err = s.changeTeam(ctx, tx, user, teamName, policy)
+		return err
+	})
+	return change, err
+}
+
+// changeTeam moves a user to teamName, "" removing them from their team, and
+// applies the review policy. Team leads become members: they lead only the
+// team they belong to.
+func (s *Service) changeTeam(ctx context.Context, tx storage.Tx, user *models.User, teamName string, policy models.ReviewPolicy) (*models.MembershipChange, error) {
+	previous := *user
+	if err := tx.Users().SetTeam(user.UserID, teamName); err != nil {
+		return nil, err
+	}
+	if user.Role == models.RoleTeamLead {
+		if err := tx.Users().SetRole(user.UserID, models.RoleMember); err != nil {
+			return nil, err
+		}
+	}
+
+	// The user is already out of the old team, so neither team can pick them
+	// as their own replacement
+	reassigned := []models.ReviewerReplacement{}
+	var err error
+	switch policy {
+	case models.ReviewPolicyReassignNewTeam:
+		reassigned, err = s.reassignPendingReviews(ctx, tx, user.UserID, teamName)
+	case models.ReviewPolicyReassignOldTeam:
+		reassigned, err = s.reassignPendingReviews(ctx, tx, user.UserID, previous.TeamName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := tx.Users().Get(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	action := "user.move"
+	if teamName == "" {
+		action = "user.remove_from_team"
+	}
+	if err := recordAudit(ctx, tx, action, models.AuditEntityUser, user.UserID, previous, updated); err != nil {
+		return nil, err
+	}
+	return &models.MembershipChange{User: *updated, Reassigned: reassigned}, nil
+}
+
+// reassignPendingReviews hands the reviews a user has not given a verdict on
+// in OPEN PRs to candidates of homeTeam and its fallback teams. Verdicts
+// already given stay. Fails with NO_CANDIDATE if any review cannot be
+// handed over.
+func (s *Service) reassignPendingReviews(ctx context.Context, tx storage.Tx, userID, homeTeam string) ([]models.ReviewerReplacement, error) {
+	prs, err := tx.PullRequests().ListByReviewer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reassigned := []models.ReviewerReplacement{}
+	for _, short := range prs {
+		if short.Status != models.StatusOpen {
+			continue
+		}
+		pr, err := loadPullRequest(tx, short.PullRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if reviewStateOf(pr.AssignedReviewers, userID) != models.ReviewPending {
+			continue
+		}
+
+		_, newReviewerID, err := s.replaceReviewer(ctx, tx, pr, userID, homeTeam, models.ReasonTeamChange)
+		if svcErr, ok := AsError(err); ok {
+			return nil, svcErr.withDetail("pull_request_id", pr.PullRequestID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		reassigned = append(reassigned, models.ReviewerReplacement{
+			PullRequestID: pr.PullRequestID,
+			OldReviewerID: userID,
+			NewReviewerID: newReviewerID,
+		})
+	}
+	return reassigned, nil
+}
+
+// addTeamMember creates or updates a user as a member of teamName. Users of
+// another team are refused rather than silently moved. Only admins may change
+// the role of a user or give a new user a role other than member.
+func addTeamMember(ctx context.Context, tx storage.Tx, teamName string, member models.TeamMember) error {
+	previous, err := tx.Users().Get(member.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		previous = nil
+	} else if err != nil {
+		return err
+	}
+	if previous != nil && previous.TeamName != "" && previous.TeamName != teamName {
+		return newError(CodeUserInTeam, "user %s is already a member of team %s", member.UserID, previous.TeamName).
+			withDetail("user_id", member.UserID).
+			withDetail("team_name", previous.TeamName)
+	}
+
+	user := models.User{
+		UserID:         member.UserID,
+		Username:       member.Username,
+		TeamName:       teamName,
+		IsActive:       member.IsActive,
+		MaxOpenReviews: member.MaxOpenReviews,
+		Role:           member.Role,
+	}
+	if user.Role == "" && previous != nil {
+		user.Role = previous.Role
+	}
+	if user.Role == "" {
+		user.Role = models.RoleMember
+	}
+	current := models.RoleMember
+	if previous != nil {
+		current = previous.Role
+	}
+	if user.Role != current {
+		if err := authorizeRole(ctx); err != nil {
+			return err
+		}
+	}
+	if err := tx.Users().Upsert(user); err != nil {
+		return err
+	}
+
+	action := "user.update"
+	if previous == nil {
+		action = "user.create"
+	}
+	return recordAudit(ctx, tx, action, models.AuditEntityUser, user.UserID, previous, user)
+}
+
+func validateTeamMembers(members []models.TeamMember) error {
+	for _, member := range members {
+		if err := validateMaxOpenReviews(member.MaxOpenReviews); err != nil {
+			return err
+		}
+		if member.Role != "" && !isRole(member.Role) {
+			return newError(CodeInvalidRequest, "role must be one of admin, team_lead, member")
+		}
+	}
+	return nil
+}
+
+// requireTeam returns the team of a user, failing with NO_TEAM for users
+// removed from their team
+func requireTeam(user *models.User) (string, error) {
+	if user.TeamName == "" {
+		return "", newError(CodeNoTeam, "user %s is not a member of any team", user.UserID).
+			withDetail("user_id", user.UserID)
+	}
+	return user.TeamName, nil
+}
+
+// reviewStateOf returns the state of a reviewer's review, or "" if they are
+// not assigned
+func reviewStateOf(reviewers []models.Reviewer, userID string) models.ReviewState {
+	for _, reviewer := range reviewers {
+		if reviewer.UserID == userID {
+			return reviewer.State
+		}
+	}
+	return ""
+}
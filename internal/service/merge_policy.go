@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// mergePolicy holds the conditions a PR must satisfy before it can be merged
+type mergePolicy struct {
+	minApprovals            int
+	blockOnChangesRequested bool
+}
+
+// authorMergePolicy returns the merge policy of the PR author's team
+func authorMergePolicy(tx storage.Tx, authorID string) (mergePolicy, error) {
+	author, err := tx.Users().Get(authorID)
+	if err != nil {
+		return mergePolicy{}, err
+	}
+	teamName, err := requireTeam(author)
+	if err != nil {
+		return mergePolicy{}, err
+	}
+	team, err := tx.Teams().Get(teamName)
+	if err != nil {
+		return mergePolicy{}, err
+	}
+	return mergePolicy{
+		minApprovals:            team.MinApprovals,
+		blockOnChangesRequested: team.BlockOnChangesRequested,
+	}, nil
+}
+
+// unmetConditions returns human-readable descriptions of the policy
+// conditions that the given reviewers do not satisfy
+func (p mergePolicy) unmetConditions(reviewers []models.Reviewer) []string {
+	var unmet []string
+
+	approvals := 0
+	var changesRequestedBy []string
+	for _, reviewer := range reviewers {
+		switch reviewer.State {
+		case models.ReviewApproved:
+			approvals++
+		case models.ReviewChangesRequested:
+			changesRequestedBy = append(changesRequestedBy, reviewer.UserID)
+		}
+	}
+
+	if approvals < p.minApprovals {
+		unmet = append(unmet, fmt.Sprintf("approvals %d/%d", approvals, p.minApprovals))
+	}
+	if p.blockOnChangesRequested && len(changesRequestedBy) > 0 {
+		unmet = append(unmet, fmt.Sprintf("changes requested by %s", strings.Join(changesRequestedBy, ", ")))
+	}
+
+	return unmet
+}
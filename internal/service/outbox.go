@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// outboxBatchSize is the number of events published per pass
+const outboxBatchSize = 100
+
+// EventSink receives published domain events. An event is published to every
+// sink at least once; sinks that cannot tolerate duplicates should
+// deduplicate by EventID.
+type EventSink interface {
+	// Name identifies the sink in errors and logs
+	Name() string
+	// Publish delivers an event. Returning an error makes the dispatcher
+	// retry the event, on every sink, in a later pass.
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// AddEventSink registers a sink that receives every published event. The
+// webhook sink is registered by NewService.
+func (s *Service) AddEventSink(sink EventSink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// emitEvent writes an event to the outbox in the transaction of the change
+// itself, so events are published only for committed changes and are not
+// lost if the service stops before publishing them.
+func emitEvent(tx storage.Tx, eventType models.EventType, data interface{}) error {
+	event := models.Event{
+		ID:         newEventID(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return tx.Outbox().Append(&models.OutboxEvent{
+		EventID:    event.ID,
+		EventType:  eventType,
+		Payload:    payload,
+		OccurredAt: event.OccurredAt,
+	})
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("generate event id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// DispatchEvents publishes pending outbox events to all sinks in the order
+// they were written. The pass stops at the first event a sink fails to
+// publish: the failure is recorded and the event is retried, on every sink,
+// in the next pass. It returns the number of published events.
+func (s *Service) DispatchEvents(ctx context.Context) (int, error) {
+	var pending []models.OutboxEvent
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		pending, err = tx.Outbox().Pending(outboxBatchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range pending {
+		if err := s.publish(ctx, event); err != nil {
+			recordErr := s.update(ctx, func(tx storage.Tx) error {
+				return tx.Outbox().RecordFailure(event.ID, err.Error())
+			})
+			if recordErr != nil {
+				return published, recordErr
+			}
+			return published, fmt.Errorf("publish event %s: %w", event.EventID, err)
+		}
+
+		err := s.update(ctx, func(tx storage.Tx) error {
+			return tx.Outbox().MarkPublished(event.ID, time.Now().UTC())
+		})
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// publish hands an event to every sink
+func (s *Service) publish(ctx context.Context, event models.OutboxEvent) error {
+	for _, sink := range s.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s sink: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// WatchEvents periodically publishes pending outbox events until ctx is done
+func (s *Service) WatchEvents(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchEvents(ctx); err != nil {
+				log.Printf("Failed to dispatch events: %v", err)
+			}
+		}
+	}
+}
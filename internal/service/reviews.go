@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// SubmitReview records a reviewer's verdict on a PR. Only the reviewer
+// themselves may submit it.
+func (s *Service) SubmitReview(ctx context.Context, prID, reviewerID string, verdict models.ReviewState) (*models.PullRequest, error) {
+	switch verdict {
+	case models.ReviewApproved, models.ReviewChangesRequested, models.ReviewCommented:
+	default:
+		return nil, newError(CodeInvalidRequest, "verdict must be one of APPROVED, CHANGES_REQUESTED, COMMENTED")
+	}
+
+	if err := authorizeReview(ctx, reviewerID); err != nil {
+		return nil, err
+	}
+
+	var pr *models.PullRequest
+	err := s.update(ctx, func(tx storage.Tx) error {
+		current, err := tx.PullRequests().Get(prID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "PR not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.Status == models.StatusMerged {
+			return newError(CodePRMerged, "cannot review merged PR")
+		}
+		if current.Status != models.StatusOpen {
+			return newError(CodePRNotOpen, "cannot review %s PR", current.Status)
+		}
+
+		before, err := loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
+		err = tx.Reviewers().SetVerdict(prID, reviewerID, verdict, time.Now())
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotAssigned, "reviewer is not assigned to this PR")
+		}
+		if err != nil {
+			return err
+		}
+
+		pr, err = loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "pull_request.review", models.AuditEntityPullRequest, prID, before, pr)
+	})
+	return pr, err
+}
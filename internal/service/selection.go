@@ -0,0 +1,202 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Candidate is an active user that can be assigned as a reviewer
+type Candidate struct {
+	UserID         string
+	OpenReviews    int
+	LastAssignedAt *time.Time
+	// MaxOpenReviews is the effective open review limit; 0 means unlimited
+	MaxOpenReviews int
+}
+
+// AtCapacity reports whether the candidate already reviews as many OPEN PRs
+// as allowed
+func (c Candidate) AtCapacity() bool {
+	return c.MaxOpenReviews > 0 && c.OpenReviews >= c.MaxOpenReviews
+}
+
+// SelectionRequest describes a single reviewer selection
+type SelectionRequest struct {
+	Candidates []Candidate
+	Count      int
+	// LastAssigned is the reviewer most recently picked by round-robin in the team
+	LastAssigned string
+}
+
+// ReviewerSelector picks up to req.Count reviewers out of req.Candidates
+type ReviewerSelector interface {
+	Strategy() models.SelectionStrategy
+	Select(req SelectionRequest) []string
+}
+
+// DefaultSelectors returns the built-in reviewer selectors
+func DefaultSelectors() []ReviewerSelector {
+	return []ReviewerSelector{
+		RandomSelector{},
+		RoundRobinSelector{},
+		LeastLoadedSelector{},
+		LeastRecentlyAssignedSelector{},
+	}
+}
+
+// RandomSelector picks reviewers uniformly at random
+type RandomSelector struct{}
+
+// Strategy returns the strategy implemented by the selector
+func (RandomSelector) Strategy() models.SelectionStrategy { return models.StrategyRandom }
+
+// Select picks up to req.Count random reviewers
+func (RandomSelector) Select(req SelectionRequest) []string {
+	return firstN(shuffleCandidates(req.Candidates), req.Count)
+}
+
+// RoundRobinSelector walks through the team ordered by user_id, continuing
+// after the reviewer that was picked last
+type RoundRobinSelector struct{}
+
+// Strategy returns the strategy implemented by the selector
+func (RoundRobinSelector) Strategy() models.SelectionStrategy { return models.StrategyRoundRobin }
+
+// Select picks up to req.Count reviewers following req.LastAssigned
+func (RoundRobinSelector) Select(req SelectionRequest) []string {
+	ordered := make([]Candidate, len(req.Candidates))
+	copy(ordered, req.Candidates)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].UserID < ordered[j].UserID
+	})
+
+	start := 0
+	for start < len(ordered) && ordered[start].UserID <= req.LastAssigned {
+		start++
+	}
+	rotated := make([]Candidate, 0, len(ordered))
+	rotated = append(rotated, ordered[start:]...)
+	rotated = append(rotated, ordered[:start]...)
+	return firstN(rotated, req.Count)
+}
+
+// LeastLoadedSelector prefers reviewers with the fewest open assignments,
+// breaking ties randomly
+type LeastLoadedSelector struct{}
+
+// Strategy returns the strategy implemented by the selector
+func (LeastLoadedSelector) Strategy() models.SelectionStrategy { return models.StrategyLeastLoaded }
+
+// Select picks up to req.Count least loaded reviewers
+func (LeastLoadedSelector) Select(req SelectionRequest) []string {
+	shuffled := shuffleCandidates(req.Candidates)
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return shuffled[i].OpenReviews < shuffled[j].OpenReviews
+	})
+	return firstN(shuffled, req.Count)
+}
+
+// LeastRecentlyAssignedSelector prefers reviewers who were assigned the
+// longest time ago (or never), breaking ties randomly
+type LeastRecentlyAssignedSelector struct{}
+
+// Strategy returns the strategy implemented by the selector
+func (LeastRecentlyAssignedSelector) Strategy() models.SelectionStrategy {
+	return models.StrategyLeastRecentlyAssigned
+}
+
+// Select picks up to req.Count least recently assigned reviewers
+func (LeastRecentlyAssignedSelector) Select(req SelectionRequest) []string {
+	shuffled := shuffleCandidates(req.Candidates)
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		a, b := shuffled[i].LastAssignedAt, shuffled[j].LastAssignedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return firstN(shuffled, req.Count)
+}
+
+func shuffleCandidates(candidates []Candidate) []Candidate {
+	shuffled := make([]Candidate, len(candidates))
+	copy(shuffled, candidates)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+func firstN(candidates []Candidate, n int) []string {
+	selected := make([]string, 0, n)
+	for i := 0; i < n && i < len(candidates); i++ {
+		selected = append(selected, candidates[i].UserID)
+	}
+	return selected
+}
+
+// RegisterSelector makes a reviewer selector available to teams under its strategy name
+func (s *Service) RegisterSelector(selector ReviewerSelector) {
+	s.selectors[selector.Strategy()] = selector
+}
+
+// selectReviewers picks reviewers for a team using the team's configured strategy
+func (s *Service) selectReviewers(tx storage.Tx, teamName string, candidates []Candidate, n int) ([]string, models.SelectionStrategy, error) {
+	team, err := tx.Teams().Get(teamName)
+	if err != nil {
+		return nil, "", err
+	}
+	cursor, err := tx.Teams().RoundRobinCursor(teamName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	selector, ok := s.selectors[team.ReviewerStrategy]
+	if !ok {
+		selector = s.selectors[models.StrategyLeastLoaded]
+	}
+
+	selected := selector.Select(SelectionRequest{
+		Candidates:   candidates,
+		Count:        n,
+		LastAssigned: cursor,
+	})
+
+	if selector.Strategy() == models.StrategyRoundRobin && len(selected) > 0 {
+		if err := tx.Teams().SetRoundRobinCursor(teamName, selected[len(selected)-1]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return selected, selector.Strategy(), nil
+}
+
+// queryCandidates returns active members of a team who are not out of office
+// together with the number of OPEN pull requests they are currently reviewing
+func queryCandidates(tx storage.Tx, teamName string, excludeIDs []string) ([]Candidate, error) {
+	loads, err := tx.Users().ActiveLoads(teamName, excludeIDs, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0, len(loads))
+	for _, load := range loads {
+		candidates = append(candidates, Candidate(load))
+	}
+	return candidates, nil
+}
+
+// withinCapacity drops candidates at their open review limit
+func withinCapacity(candidates []Candidate) []Candidate {
+	available := make([]Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if !c.AtCapacity() {
+			available = append(available, c)
+		}
+	}
+	return available
+}
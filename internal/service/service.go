@@ -1,523 +1,664 @@
 package service
 
 import (
-	"database/sql"
-	"fmt"
-	"math/rand"
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
 )
 
 type Service struct {
-	db *sql.DB
+	store         storage.Store
+	selectors     map[models.SelectionStrategy]ReviewerSelector
+	webhookClient *http.Client
+	webhookRetry  WebhookRetryPolicy
+	sinks         []EventSink
+	githubSecret  string
+	gitlabToken   string
+	adminToken    string
 }
 
-func NewService(db *sql.DB) *Service {
-	return &Service{db: db}
+func NewService(store storage.Store) *Service {
+	s := &Service{
+		store:         store,
+		selectors:     make(map[models.SelectionStrategy]ReviewerSelector),
+		webhookClient: &http.Client{Timeout: 10 * time.Second},
+		webhookRetry:  DefaultWebhookRetryPolicy(),
+	}
+	for _, selector := range DefaultSelectors() {
+		s.RegisterSelector(selector)
+	}
+	s.AddEventSink(webhookSink{s})
+	return s
 }
 
-// CreateTeam creates a team and its members
-func (s *Service) CreateTeam(team models.Team) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+// update runs fn in a read-write transaction bound to ctx
+func (s *Service) update(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return contextError(ctx, s.store.Update(ctx, fn))
+}
 
-	// Check if team already exists
-	var exists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", team.TeamName).Scan(&exists)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return fmt.Errorf("TEAM_EXISTS: team_name already exists")
-	}
+// view runs fn in a read-only transaction bound to ctx
+func (s *Service) view(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return contextError(ctx, s.store.View(ctx, fn))
+}
 
-	// Create team
-	_, err = tx.Exec("INSERT INTO teams (team_name) VALUES ($1)", team.TeamName)
-	if err != nil {
+// CreateTeam creates a team and its members. Members who already belong to
+// another team are refused; they are moved with MoveTeamMember. Only admins
+// may give members a role other than member.
+func (s *Service) CreateTeam(ctx context.Context, team models.Team) error {
+	if err := validateTeamMembers(team.Members); err != nil {
 		return err
 	}
 
-	// Create/update users
-	for _, member := range team.Members {
-		_, err = tx.Exec(`
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (user_id) 
-			DO UPDATE SET username = EXCLUDED.username, team_name = EXCLUDED.team_name, is_active = EXCLUDED.is_active, updated_at = CURRENT_TIMESTAMP
-		`, member.UserID, member.Username, team.TeamName, member.IsActive)
-		if err != nil {
+	return s.update(ctx, func(tx storage.Tx) error {
+		// Check if team already exists
+		_, err := tx.Teams().Get(team.TeamName)
+		if err == nil {
+			return newError(CodeTeamExists, "team_name already exists")
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
 			return err
 		}
-	}
 
-	return tx.Commit()
+		// Create team
+		if err := tx.Teams().Create(team.TeamName); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, tx, "team.create", models.AuditEntityTeam, team.TeamName, nil, team); err != nil {
+			return err
+		}
+
+		// Create/update users
+		for _, member := range team.Members {
+			if err := addTeamMember(ctx, tx, team.TeamName, member); err != nil {
+				return err
+			}
+		}
+
+		return emitEvent(tx, models.EventTeamCreated, map[string]interface{}{"team": team})
+	})
 }
 
 // GetTeam retrieves a team with its members
-func (s *Service) GetTeam(teamName string) (*models.Team, error) {
-	// Check if team exists
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
-	if err != nil {
-		return nil, err
-	}
-	if !exists {
-		return nil, fmt.Errorf("NOT_FOUND: team not found")
-	}
+func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	var team *models.Team
+	err := s.view(ctx, func(tx storage.Tx) error {
+		// Check if team exists
+		_, err := tx.Teams().Get(teamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		}
+		if err != nil {
+			return err
+		}
 
-	// Get team members
-	rows, err := s.db.Query(`
-		SELECT user_id, username, is_active
-		FROM users
-		WHERE team_name = $1
-		ORDER BY user_id
-	`, teamName)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+		// Get team members
+		users, err := tx.Users().ListByTeam(teamName)
+		if err != nil {
+			return err
+		}
 
-	var members []models.TeamMember
-	for rows.Next() {
-		var member models.TeamMember
-		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive); err != nil {
-			return nil, err
+		var members []models.TeamMember
+		for _, user := range users {
+			members = append(members, models.TeamMember{
+				UserID:         user.UserID,
+				Username:       user.Username,
+				IsActive:       user.IsActive,
+				MaxOpenReviews: user.MaxOpenReviews,
+				Role:           user.Role,
+			})
 		}
-		members = append(members, member)
-	}
 
-	return &models.Team{
-		TeamName: teamName,
-		Members:  members,
-	}, nil
+		team = &models.Team{
+			TeamName: teamName,
+			Members:  members,
+		}
+		return nil
+	})
+	return team, err
 }
 
 // SetUserActive sets the active status of a user
-func (s *Service) SetUserActive(userID string, isActive bool) (*models.User, error) {
-	var user models.User
-	err := s.db.QueryRow(`
-		UPDATE users
-		SET is_active = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = $2
-		RETURNING user_id, username, team_name, is_active
-	`, isActive, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("NOT_FOUND: user not found")
-	}
-	if err != nil {
+func (s *Service) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
+	var user *models.User
+	err := s.update(ctx, func(tx storage.Tx) error {
+		previous, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Users().SetActive(userID, isActive); err != nil {
+			return err
+		}
+
+		user, err = tx.Users().Get(userID)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, "user.set_active", models.AuditEntityUser, userID, previous, user); err != nil {
+			return err
+		}
+		if previous.IsActive && !isActive {
+			return emitEvent(tx, models.EventUserDeactivated, map[string]interface{}{"user": user})
+		}
+		return nil
+	})
+	return user, err
+}
+
+// SetUserMaxOpenReviews sets the open review limit of a user; nil falls back
+// to the team default
+func (s *Service) SetUserMaxOpenReviews(ctx context.Context, userID string, limit *int) (*models.User, error) {
+	if err := validateMaxOpenReviews(limit); err != nil {
 		return nil, err
 	}
 
-	return &user, nil
+	var user *models.User
+	err := s.update(ctx, func(tx storage.Tx) error {
+		previous, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, previous.TeamName); err != nil {
+			return err
+		}
+		if err := tx.Users().SetMaxOpenReviews(userID, limit); err != nil {
+			return err
+		}
+
+		user, err = tx.Users().Get(userID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "user.set_max_open_reviews", models.AuditEntityUser, userID, previous, user)
+	})
+	return user, err
+}
+
+// CreatePullRequestOptions holds optional parameters of CreatePullRequest
+type CreatePullRequestOptions struct {
+	// ReviewersCount overrides the team's default number of reviewers.
+	// Unlike the team default it is strict: the PR is not created if the
+	// team cannot supply that many reviewers.
+	ReviewersCount *int
+	// Draft creates the PR in DRAFT status without reviewers
+	Draft bool
+	// ChangedFiles are the paths touched by the PR. Owners of these paths
+	// according to the CODEOWNERS rules are assigned first.
+	ChangedFiles []string
 }
 
 // CreatePullRequest creates a PR and assigns reviewers
-func (s *Service) CreatePullRequest(prID, prName, authorID string) (*models.PullRequest, error) {
-	tx, err := s.db.Begin()
+func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID string, opts CreatePullRequestOptions) (*models.PullRequest, error) {
+	if opts.ReviewersCount != nil {
+		if err := validateReviewersCount(*opts.ReviewersCount); err != nil {
+			return nil, err
+		}
+	}
+	changedFiles, err := normalizeChangedFiles(opts.ChangedFiles)
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
 
-	// Check if PR already exists
-	var exists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)", prID).Scan(&exists)
+	var pr *models.PullRequest
+	err = s.update(ctx, func(tx storage.Tx) error {
+		// Check if PR already exists
+		_, err := tx.PullRequests().Get(prID)
+		if err == nil {
+			return newError(CodePRExists, "PR id already exists")
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+
+		// Get author's team
+		author, err := tx.Users().Get(authorID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "author not found")
+		}
+		if err != nil {
+			return err
+		}
+		teamName, err := requireTeam(author)
+		if err != nil {
+			return err
+		}
+
+		status := models.StatusOpen
+		if opts.Draft {
+			status = models.StatusDraft
+		}
+
+		// Create PR
+		now := time.Now()
+		created := models.PullRequest{
+			PullRequestID:   prID,
+			PullRequestName: prName,
+			AuthorID:        authorID,
+			Status:          status,
+			ReviewersCount:  opts.ReviewersCount,
+			ChangedFiles:    changedFiles,
+			CreatedAt:       &now,
+		}
+		if err := tx.PullRequests().Create(created); err != nil {
+			return err
+		}
+
+		// Reviewers are assigned once the PR leaves DRAFT
+		if !opts.Draft {
+			if err := s.assignInitialReviewers(ctx, tx, &created, teamName); err != nil {
+				return err
+			}
+		}
+
+		pr, err = loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, "pull_request.create", models.AuditEntityPullRequest, prID, nil, pr); err != nil {
+			return err
+		}
+		return emitEvent(tx, models.EventPRCreated, map[string]interface{}{"pr": pr})
+	})
+	return pr, err
+}
+
+// assignInitialReviewers selects reviewers for a PR leaving DRAFT (or created
+// as OPEN) and records the strategy used. Every CODEOWNERS rule owning the
+// changed files gets one of its owners first; the remaining slots are filled
+// from the author's team and, once it has no suitable candidates left, from
+// its fallback teams. Reviewers at capacity are skipped; if that leaves the PR short of
+// reviewers it is flagged as understaffed.
+func (s *Service) assignInitialReviewers(ctx context.Context, tx storage.Tx, pr *models.PullRequest, teamName string) error {
+	// Code owners of the changed files take the first slots
+	owners, err := selectCodeOwners(tx, pr.ChangedFiles, []string{pr.AuthorID})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if exists {
-		return nil, fmt.Errorf("PR_EXISTS: PR id already exists")
+	exclude := []string{pr.AuthorID}
+	for _, owner := range owners {
+		exclude = append(exclude, owner.UserID)
 	}
 
-	// Get author's team
-	var teamName string
-	err = tx.QueryRow("SELECT team_name FROM users WHERE user_id = $1", authorID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("NOT_FOUND: author not found")
-	}
+	// Get active reviewers from author's team (excluding author and owners) and its fallback teams
+	pools, err := queryCandidatePools(tx, teamName, exclude)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	candidates := countCandidates(pools) + len(owners)
 
-	// Create PR
-	now := time.Now()
-	_, err = tx.Exec(`
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, prID, prName, authorID, models.StatusOpen, now)
+	// Determine how many reviewers are needed
+	team, err := tx.Teams().Get(teamName)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	reviewersCount := team.ReviewersCount
+	if pr.ReviewersCount != nil {
+		reviewersCount = *pr.ReviewersCount
+		if candidates < reviewersCount {
+			return newError(CodeNotEnoughReviewers, "team %s can supply only %d of %d requested reviewers", teamName, candidates, reviewersCount).
+				withDetail("available", candidates).
+				withDetail("requested", reviewersCount)
+		}
 	}
 
-	// Get active reviewers from author's team (excluding author)
-	rows, err := tx.Query(`
-		SELECT user_id
-		FROM users
-		WHERE team_name = $1 AND is_active = true AND user_id != $2
-		ORDER BY user_id
-	`, teamName, authorID)
+	// Fill the remaining slots using each team's strategy
+	remaining := reviewersCount - len(owners)
+	if remaining < 0 {
+		remaining = 0
+	}
+	selected, strategy, err := s.selectFromPools(tx, pools, remaining)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	reviewers := append(owners, selected...)
 
-	var candidates []string
-	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
-			return nil, err
+	now := time.Now()
+	for _, reviewer := range reviewers {
+		reviewer.State = models.ReviewPending
+		reviewer.AssignedAt = &now
+		if err := tx.Reviewers().Add(pr.PullRequestID, reviewer); err != nil {
+			return err
 		}
-		candidates = append(candidates, userID)
-	}
-
-	// Assign up to 2 reviewers randomly
-	reviewers := s.selectRandomReviewers(candidates, 2)
-	for _, reviewerID := range reviewers {
-		_, err = tx.Exec(`
-			INSERT INTO pr_reviewers (pull_request_id, reviewer_id)
-			VALUES ($1, $2)
-		`, prID, reviewerID)
+		err := recordAssignment(ctx, tx, models.AssignmentEvent{
+			PullRequestID: pr.PullRequestID,
+			Action:        models.AssignmentAssigned,
+			ReviewerID:    reviewer.UserID,
+			FallbackTeam:  reviewer.FallbackTeam,
+			Reason:        models.ReasonInitial,
+		})
 		if err != nil {
-			return nil, err
+			return err
+		}
+		err = emitEvent(tx, models.EventReviewerAssigned, map[string]interface{}{
+			"pull_request_id": pr.PullRequestID,
+			"reviewer":        reviewer,
+		})
+		if err != nil {
+			return err
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, err
-	}
+	pr.AssignmentStrategy = strategy
+	pr.Understaffed = len(reviewers) < minInt(reviewersCount, candidates)
+	return tx.PullRequests().Update(*pr)
+}
 
-	// Fetch the created PR
-	return s.GetPullRequest(prID)
+// normalizeChangedFiles cleans up changed file paths relative to the
+// repository root and drops duplicates
+func normalizeChangedFiles(files []string) ([]string, error) {
+	normalized := make([]string, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		clean := strings.TrimPrefix(path.Clean("/"+strings.TrimSpace(file)), "/")
+		if clean == "" {
+			return nil, newError(CodeInvalidRequest, "changed file path must not be empty")
+		}
+		if !seen[clean] {
+			seen[clean] = true
+			normalized = append(normalized, clean)
+		}
+	}
+	return normalized, nil
 }
 
 // GetPullRequest retrieves a PR with its reviewers
-func (s *Service) GetPullRequest(prID string) (*models.PullRequest, error) {
-	var pr models.PullRequest
-	var createdAt, mergedAt sql.NullTime
-
-	err := s.db.QueryRow(`
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
-		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+func (s *Service) GetPullRequest(ctx context.Context, prID string) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		pr, err = loadPullRequest(tx, prID)
+		return err
+	})
+	return pr, err
+}
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("NOT_FOUND: PR not found")
+// loadPullRequest reads a PR together with its reviewers
+func loadPullRequest(tx storage.Tx, prID string) (*models.PullRequest, error) {
+	pr, err := tx.PullRequests().Get(prID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, newError(CodeNotFound, "PR not found")
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	if createdAt.Valid {
-		pr.CreatedAt = &createdAt.Time
-	}
-	if mergedAt.Valid {
-		pr.MergedAt = &mergedAt.Time
-	}
-
 	// Get reviewers
-	rows, err := s.db.Query(`
-		SELECT reviewer_id
-		FROM pr_reviewers
-		WHERE pull_request_id = $1
-		ORDER BY reviewer_id
-	`, prID)
+	pr.AssignedReviewers, err = tx.Reviewers().List(prID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
-			return nil, err
-		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
-	}
+	return pr, nil
+}
 
-	return &pr, nil
+// MergePullRequestOptions holds optional parameters of MergePullRequest
+type MergePullRequestOptions struct {
+	// Force merges the PR even if the team's merge policy is not satisfied.
+	// Only admins may force; the bypass is recorded on the PR.
+	Force bool
 }
 
-// MergePullRequest marks a PR as merged (idempotent)
-func (s *Service) MergePullRequest(prID string) (*models.PullRequest, error) {
-	// Check if PR exists
-	var currentStatus string
-	var mergedAt sql.NullTime
-	err := s.db.QueryRow(`
-		SELECT status, merged_at
-		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&currentStatus, &mergedAt)
+// MergePullRequest marks a PR as merged (idempotent). Only admins and team
+// leads of the author's team may merge, and only admins may force a merge.
+func (s *Service) MergePullRequest(ctx context.Context, prID string, opts MergePullRequestOptions) (*models.PullRequest, error) {
+	var pr *models.PullRequest
+	err := s.update(ctx, func(tx storage.Tx) error {
+		// Check if PR exists
+		var err error
+		pr, err = loadPullRequest(tx, prID)
+		if err != nil {
+			return err
+		}
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("NOT_FOUND: PR not found")
-	}
-	if err != nil {
-		return nil, err
-	}
+		if err := authorizeManage(ctx, tx, pr); err != nil {
+			return err
+		}
+		if opts.Force {
+			if err := authorizeForce(ctx); err != nil {
+				return err
+			}
+		}
 
-	// If already merged, just return it
-	if currentStatus == string(models.StatusMerged) {
-		return s.GetPullRequest(prID)
-	}
+		// If already merged, just return it
+		if pr.Status == models.StatusMerged {
+			return nil
+		}
+		if err := checkTransition(actionMerge, pr.Status); err != nil {
+			return err
+		}
 
-	// Merge the PR
-	now := time.Now()
-	_, err = s.db.Exec(`
-		UPDATE pull_requests
-		SET status = $1, merged_at = $2
-		WHERE pull_request_id = $3
-	`, models.StatusMerged, now, prID)
+		// Check the author team's merge policy
+		policy, err := authorMergePolicy(tx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+		unmet := policy.unmetConditions(pr.AssignedReviewers)
+		if len(unmet) > 0 && !opts.Force {
+			return newError(CodeMergeBlocked, "merge policy not satisfied: %s", strings.Join(unmet, "; ")).
+				withDetail("unmet_conditions", unmet)
+		}
+
+		// Merge the PR
+		before := *pr
+		now := time.Now()
+		pr.Status = models.StatusMerged
+		pr.MergedAt = &now
+		pr.ForceMerged = len(unmet) > 0
+		if err := tx.PullRequests().Update(*pr); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, "pull_request.merge", models.AuditEntityPullRequest, prID, before, pr); err != nil {
+			return err
+		}
+		return emitEvent(tx, models.EventPRMerged, map[string]interface{}{"pr": pr})
+	})
 	if err != nil {
 		return nil, err
 	}
+	return pr, nil
+}
 
-	return s.GetPullRequest(prID)
+// ReassignReviewer reassigns a reviewer. Only admins and team leads of the
+// author's team may do so.
+func (s *Service) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+	return s.reassignReviewer(ctx, prID, oldUserID, models.ReasonManual)
 }
 
-// ReassignReviewer reassigns a reviewer
-func (s *Service) ReassignReviewer(prID, oldUserID string) (*models.PullRequest, string, error) {
-	tx, err := s.db.Begin()
+// reassignReviewer replaces a reviewer and records why in the assignment history
+func (s *Service) reassignReviewer(ctx context.Context, prID, oldUserID string, reason models.AssignmentReason) (*models.PullRequest, string, error) {
+	var pr *models.PullRequest
+	var newReviewerID string
+	err := s.update(ctx, func(tx storage.Tx) error {
+		var err error
+		pr, newReviewerID, err = s.reassignInTx(ctx, tx, prID, oldUserID, reason)
+		return err
+	})
 	if err != nil {
 		return nil, "", err
 	}
-	defer tx.Rollback()
 
-	// Get PR
-	var pr models.PullRequest
-	var status string
-	err = tx.QueryRow(`
-		SELECT pull_request_id, pull_request_name, author_id, status
-		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &status)
+	return pr, newReviewerID, nil
+}
 
-	if err == sql.ErrNoRows {
-		return nil, "", fmt.Errorf("NOT_FOUND: PR not found")
-	}
+// reassignInTx is reassignReviewer within an existing transaction. Service
+// errors are returned before anything is written.
+func (s *Service) reassignInTx(ctx context.Context, tx storage.Tx, prID, oldUserID string, reason models.AssignmentReason) (*models.PullRequest, string, error) {
+	// Get PR
+	pr, err := loadPullRequest(tx, prID)
 	if err != nil {
 		return nil, "", err
 	}
+	if reason == models.ReasonManual {
+		if err := authorizeManage(ctx, tx, pr); err != nil {
+			return nil, "", err
+		}
+	}
 
 	// Check if PR is merged
-	if status == string(models.StatusMerged) {
-		return nil, "", fmt.Errorf("PR_MERGED: cannot reassign on merged PR")
+	if pr.Status == models.StatusMerged {
+		return nil, "", newError(CodePRMerged, "cannot reassign on merged PR")
+	}
+	if pr.Status != models.StatusOpen {
+		return nil, "", newError(CodePRNotOpen, "cannot reassign on %s PR", pr.Status)
 	}
 
 	// Check if old reviewer is assigned
-	var isAssigned bool
-	err = tx.QueryRow(`
-		SELECT EXISTS(SELECT 1 FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2)
-	`, prID, oldUserID).Scan(&isAssigned)
-	if err != nil {
-		return nil, "", err
-	}
-	if !isAssigned {
-		return nil, "", fmt.Errorf("NOT_ASSIGNED: reviewer is not assigned to this PR")
+	currentReviewers := pr.ReviewerIDs()
+	if !containsString(currentReviewers, oldUserID) {
+		return nil, "", newError(CodeNotAssigned, "reviewer is not assigned to this PR")
 	}
 
 	// Get old reviewer's team
-	var teamName string
-	err = tx.QueryRow("SELECT team_name FROM users WHERE user_id = $1", oldUserID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, "", fmt.Errorf("NOT_FOUND: old reviewer not found")
+	oldReviewer, err := tx.Users().Get(oldUserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", newError(CodeNotFound, "old reviewer not found")
 	}
 	if err != nil {
 		return nil, "", err
 	}
+	homeTeam := oldReviewer.TeamName
 
-	// Get current reviewers to exclude them
-	var currentReviewers []string
-	rows, err := tx.Query(`
-		SELECT reviewer_id
-		FROM pr_reviewers
-		WHERE pull_request_id = $1
-	`, prID)
-	if err != nil {
-		return nil, "", err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+	// A reviewer borrowed from a fallback team is replaced the way they
+	// were picked: from the author's team first. So is a reviewer who has
+	// been removed from their team.
+	if homeTeam == "" || borrowedFrom(pr.AssignedReviewers, oldUserID) != "" {
+		author, err := tx.Users().Get(pr.AuthorID)
+		if err != nil {
 			return nil, "", err
 		}
-		currentReviewers = append(currentReviewers, reviewerID)
-	}
-	rows.Close()
-
-	// Get active candidates from old reviewer's team (excluding current reviewers and old reviewer)
-	query := `
-		SELECT user_id
-		FROM users
-		WHERE team_name = $1 AND is_active = true AND user_id != $2
-	`
-	args := []interface{}{teamName, oldUserID}
-	for i, reviewerID := range currentReviewers {
-		if reviewerID != oldUserID {
-			query += fmt.Sprintf(" AND user_id != $%d", i+3)
-			args = append(args, reviewerID)
+		if homeTeam, err = requireTeam(author); err != nil {
+			return nil, "", err
 		}
 	}
-	query += " ORDER BY user_id"
 
-	rows, err = tx.Query(query, args...)
+	return s.replaceReviewer(ctx, tx, pr, oldUserID, homeTeam, reason)
+}
+
+// replaceReviewer swaps an assigned reviewer of an OPEN PR for a candidate
+// picked from homeTeam and its fallback teams, and records the change in the
+// assignment history and the audit log. It returns the reloaded PR and the
+// new reviewer.
+func (s *Service) replaceReviewer(ctx context.Context, tx storage.Tx, pr *models.PullRequest, oldUserID, homeTeam string, reason models.AssignmentReason) (*models.PullRequest, string, error) {
+	prID := pr.PullRequestID
+
+	// Get active candidates from the team and its fallback teams (excluding current reviewers and the author)
+	pools, err := queryCandidatePools(tx, homeTeam, append(pr.ReviewerIDs(), pr.AuthorID))
 	if err != nil {
 		return nil, "", err
 	}
-	defer rows.Close()
-
-	var candidates []string
-	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
-			return nil, "", err
-		}
-		candidates = append(candidates, userID)
-	}
 
-	if len(candidates) == 0 {
-		return nil, "", fmt.Errorf("NO_CANDIDATE: no active replacement candidate in team")
+	candidates := countCandidates(pools)
+	if candidates == 0 {
+		return nil, "", newError(CodeNoCandidate, "no active replacement candidate in team")
 	}
 
-	// Select random replacement
-	newReviewerID := candidates[rand.Intn(len(candidates))]
-
-	// Replace reviewer
-	_, err = tx.Exec(`
-		UPDATE pr_reviewers
-		SET reviewer_id = $1
-		WHERE pull_request_id = $2 AND reviewer_id = $3
-	`, newReviewerID, prID, oldUserID)
+	// Select replacement using each team's strategy
+	selected, _, err := s.selectFromPools(tx, pools, 1)
 	if err != nil {
 		return nil, "", err
 	}
+	if len(selected) == 0 {
+		return nil, "", newError(CodeNoCandidate, "all replacement candidates in team are at capacity").
+			withDetail("at_capacity", candidates)
+	}
+	replacement := selected[0]
 
-	if err := tx.Commit(); err != nil {
+	// Replace reviewer
+	now := time.Now()
+	replacement.State = models.ReviewPending
+	replacement.AssignedAt = &now
+	if err := tx.Reviewers().Replace(prID, oldUserID, replacement); err != nil {
 		return nil, "", err
 	}
-
-	updatedPR, err := s.GetPullRequest(prID)
+	err = recordAssignment(ctx, tx, models.AssignmentEvent{
+		PullRequestID:      prID,
+		Action:             models.AssignmentReassigned,
+		ReviewerID:         replacement.UserID,
+		PreviousReviewerID: oldUserID,
+		FallbackTeam:       replacement.FallbackTeam,
+		Reason:             reason,
+	})
 	if err != nil {
 		return nil, "", err
 	}
-
-	return updatedPR, newReviewerID, nil
-}
-
-// GetUserReviewPRs gets PRs assigned to a user
-func (s *Service) GetUserReviewPRs(userID string) ([]models.PullRequestShort, error) {
-	// Check if user exists
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE user_id = $1)", userID).Scan(&exists)
+	err = emitEvent(tx, models.EventReviewerReassigned, map[string]interface{}{
+		"pull_request_id": prID,
+		"old_reviewer_id": oldUserID,
+		"reviewer":        replacement,
+	})
 	if err != nil {
-		return nil, err
-	}
-	if !exists {
-		return nil, fmt.Errorf("NOT_FOUND: user not found")
+		return nil, "", err
 	}
 
-	// Get PRs
-	rows, err := s.db.Query(`
-		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
-		FROM pull_requests pr
-		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		WHERE prr.reviewer_id = $1
-		ORDER BY pr.created_at DESC
-	`, userID)
+	after, err := loadPullRequest(tx, prID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer rows.Close()
-
-	var prs []models.PullRequestShort
-	for rows.Next() {
-		var pr models.PullRequestShort
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
-			return nil, err
-		}
-		prs = append(prs, pr)
+	if err := recordAudit(ctx, tx, "pull_request.reassign", models.AuditEntityPullRequest, prID, pr, after); err != nil {
+		return nil, "", err
 	}
-
-	return prs, nil
+	return after, replacement.UserID, nil
 }
 
-// selectRandomReviewers selects up to n random reviewers from candidates
-func (s *Service) selectRandomReviewers(candidates []string, n int) []string {
-	if len(candidates) == 0 {
-		return []string{}
+// GetUserReviewPRs returns a page of the PRs a user is assigned to review.
+// Without a status filter CLOSED PRs are left out.
+func (s *Service) GetUserReviewPRs(ctx context.Context, userID string, filter models.PullRequestFilter, cursor string) (*models.PullRequestPage, error) {
+	filter.ReviewerID = userID
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []models.PullRequestStatus{models.StatusDraft, models.StatusOpen, models.StatusMerged}
 	}
 
-	if len(candidates) <= n {
-		// Shuffle and return all
-		shuffled := make([]string, len(candidates))
-		copy(shuffled, candidates)
-		rand.Shuffle(len(shuffled), func(i, j int) {
-			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-		})
-		return shuffled
-	}
+	var page *models.PullRequestPage
+	err := s.view(ctx, func(tx storage.Tx) error {
+		// Check if user exists
+		_, err := tx.Users().Get(userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
 
-	// Select n random reviewers
-	selected := make([]string, 0, n)
-	indices := rand.Perm(len(candidates))
-	for i := 0; i < n && i < len(indices); i++ {
-		selected = append(selected, candidates[indices[i]])
-	}
-	return selected
+		page, err = s.listPullRequests(tx, filter, cursor)
+		return err
+	})
+	return page, err
 }
 
-// Helper function to check error type
-func IsErrorCode(err error, code string) bool {
-	if err == nil {
-		return false
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
-	errStr := err.Error()
-	return len(errStr) > len(code) && errStr[:len(code)] == code
+	return b
 }
 
-func GetErrorCode(err error) string {
-	if err == nil {
-		return ""
-	}
-	errStr := err.Error()
-	colonIdx := -1
-	for i, r := range errStr {
-		if r == ':' {
-			colonIdx = i
-			break
+// borrowedFrom returns the fallback team a reviewer was borrowed from, or ""
+func borrowedFrom(reviewers []models.Reviewer, userID string) string {
+	for _, reviewer := range reviewers {
+		if reviewer.UserID == userID {
+			return reviewer.FallbackTeam
 		}
 	}
-	if colonIdx == -1 {
-		return ""
-	}
-	return errStr[:colonIdx]
+	return ""
 }
 
-func GetErrorMessage(err error) string {
-	if err == nil {
-		return ""
-	}
-	errStr := err.Error()
-	colonIdx := -1
-	for i, r := range errStr {
-		if r == ':' {
-			colonIdx = i
-			break
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
 		}
 	}
-	if colonIdx == -1 {
-		return errStr
-	}
-	if colonIdx+2 < len(errStr) {
-		return errStr[colonIdx+2:]
-	}
-	return ""
+	return false
 }
-
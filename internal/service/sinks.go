@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+// LogSink writes every event to the standard logger
+type LogSink struct{}
+
+func (LogSink) Name() string {
+	return "log"
+}
+
+func (LogSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	log.Printf("Event %s %s: %s", event.EventType, event.EventID, event.Payload)
+	return nil
+}
+
+// FileSink appends every event to a file as a line of JSON
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens the file at path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+func (f *FileSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	line := append(append([]byte(nil), event.Payload...), '\n')
+	if _, err := f.file.Write(line); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Close closes the underlying file
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// RenameTeam renames a team. Members, fallback teams, reviewers borrowed
+// from the team and CODEOWNERS rules follow the new name in the same
+// transaction. The audit log and the assignment history keep the old name,
+// and the audit trail of the old name ends with the rename.
+func (s *Service) RenameTeam(ctx context.Context, teamName, newName string) error {
+	if newName == "" {
+		return newError(CodeInvalidRequest, "new_team_name is required")
+	}
+	if newName == teamName {
+		return newError(CodeInvalidRequest, "new_team_name must differ from team_name")
+	}
+
+	return s.update(ctx, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		} else if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, teamName); err != nil {
+			return err
+		}
+		if _, err := tx.Teams().Get(newName); err == nil {
+			return newError(CodeTeamExists, "team_name already exists")
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+
+		if err := tx.Teams().Rename(teamName, newName); err != nil {
+			return err
+		}
+		if err := replaceCodeOwnerTeam(tx, teamName, newName); err != nil {
+			return err
+		}
+
+		before := map[string]string{"team_name": teamName}
+		after := map[string]string{"team_name": newName}
+		return recordAudit(ctx, tx, "team.rename", models.AuditEntityTeam, teamName, before, after)
+	})
+}
+
+// DeleteTeam deletes a team. Its members move to targetTeam, keeping their
+// reviews, or are left without a team if targetTeam is empty; the latter is
+// refused while members author DRAFT or OPEN PRs. The team is dropped from
+// fallback teams, and CODEOWNERS rules hand its paths to targetTeam or lose
+// it as an owner.
+func (s *Service) DeleteTeam(ctx context.Context, teamName, targetTeam string) (*models.TeamDeletion, error) {
+	if targetTeam == teamName {
+		return nil, newError(CodeInvalidRequest, "target_team must differ from team_name")
+	}
+
+	var deletion *models.TeamDeletion
+	err := s.update(ctx, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get(teamName); errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		} else if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, teamName); err != nil {
+			return err
+		}
+		if targetTeam != "" {
+			if _, err := tx.Teams().Get(targetTeam); errors.Is(err, storage.ErrNotFound) {
+				return newError(CodeNotFound, "target team not found")
+			} else if err != nil {
+				return err
+			}
+		}
+
+		// PRs of authors without a team have nobody to review or merge them
+		if targetTeam == "" {
+			open, err := tx.PullRequests().List(models.PullRequestFilter{
+				TeamName: teamName,
+				Statuses: []models.PullRequestStatus{models.StatusDraft, models.StatusOpen},
+				Limit:    1,
+			})
+			if err != nil {
+				return err
+			}
+			if len(open) > 0 {
+				return newError(CodeHasOpenPRs, "team members author open PRs; give a target team for them").
+					withDetail("pull_request_id", open[0].PullRequestID)
+			}
+		}
+
+		members, err := tx.Users().ListByTeam(teamName)
+		if err != nil {
+			return err
+		}
+		deletion = &models.TeamDeletion{TeamName: teamName, TargetTeam: targetTeam, Members: []string{}}
+		team := models.Team{TeamName: teamName}
+		for i := range members {
+			member := members[i]
+			if _, err := s.changeTeam(ctx, tx, &member, targetTeam, models.ReviewPolicyKeep); err != nil {
+				return err
+			}
+			deletion.Members = append(deletion.Members, member.UserID)
+			team.Members = append(team.Members, models.TeamMember{
+				UserID:         member.UserID,
+				Username:       member.Username,
+				IsActive:       member.IsActive,
+				MaxOpenReviews: member.MaxOpenReviews,
+				Role:           member.Role,
+			})
+		}
+
+		if err := replaceCodeOwnerTeam(tx, teamName, targetTeam); err != nil {
+			return err
+		}
+		if err := tx.Teams().Delete(teamName); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "team.delete", models.AuditEntityTeam, teamName, team, nil)
+	})
+	return deletion, err
+}
+
+// replaceCodeOwnerTeam makes newName the owner of every path teamName owns;
+// "" removes teamName from the owners
+func replaceCodeOwnerTeam(tx storage.Tx, teamName, newName string) error {
+	rules, err := tx.CodeOwners().List()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i, rule := range rules {
+		if !containsString(rule.Teams, teamName) {
+			continue
+		}
+		teams := []string{}
+		for _, owner := range rule.Teams {
+			if owner == teamName {
+				owner = newName
+			}
+			if owner != "" && !containsString(teams, owner) {
+				teams = append(teams, owner)
+			}
+		}
+		rules[i].Teams = teams
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return tx.CodeOwners().Replace(rules)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+const (
+	// MinReviewersCount is the smallest allowed number of reviewers per PR
+	MinReviewersCount = 1
+	// MaxReviewersCount is the largest allowed number of reviewers per PR
+	MaxReviewersCount = 10
+)
+
+func validateMaxOpenReviews(limit *int) error {
+	if limit != nil && *limit < 1 {
+		return newError(CodeInvalidRequest, "max_open_reviews must be positive")
+	}
+	return nil
+}
+
+func validateReviewersCount(count int) error {
+	if count < MinReviewersCount || count > MaxReviewersCount {
+		return newError(CodeInvalidRequest, "reviewers_count must be between %d and %d", MinReviewersCount, MaxReviewersCount)
+	}
+	return nil
+}
+
+// GetTeamSettings retrieves reviewer assignment settings of a team
+func (s *Service) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	var settings *models.TeamSettings
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		settings, err = tx.Teams().Get(teamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		}
+		return err
+	})
+	return settings, err
+}
+
+// UpdateTeamSettings applies a partial update to team settings
+func (s *Service) UpdateTeamSettings(ctx context.Context, update models.TeamSettingsUpdate) (*models.TeamSettings, error) {
+	if update.ReviewerStrategy != nil {
+		if _, ok := s.selectors[*update.ReviewerStrategy]; !ok {
+			return nil, newError(CodeInvalidRequest, "unknown reviewer strategy %q", *update.ReviewerStrategy)
+		}
+	}
+	if update.ReviewersCount != nil {
+		if err := validateReviewersCount(*update.ReviewersCount); err != nil {
+			return nil, err
+		}
+	}
+	if update.MinApprovals != nil && (*update.MinApprovals < 0 || *update.MinApprovals > MaxReviewersCount) {
+		return nil, newError(CodeInvalidRequest, "min_approvals must be between 0 and %d", MaxReviewersCount)
+	}
+	if update.DefaultMaxOpenReviews != nil && *update.DefaultMaxOpenReviews < 0 {
+		return nil, newError(CodeInvalidRequest, "default_max_open_reviews must not be negative")
+	}
+
+	var settings *models.TeamSettings
+	err := s.update(ctx, func(tx storage.Tx) error {
+		var err error
+		settings, err = tx.Teams().Get(update.TeamName)
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeNotFound, "team not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := authorizeTeam(ctx, update.TeamName); err != nil {
+			return err
+		}
+		before := *settings
+
+		if update.ReviewerStrategy != nil {
+			settings.ReviewerStrategy = *update.ReviewerStrategy
+			if err := tx.Teams().SetRoundRobinCursor(update.TeamName, ""); err != nil {
+				return err
+			}
+		}
+		if update.ReviewersCount != nil {
+			settings.ReviewersCount = *update.ReviewersCount
+		}
+		if update.MinApprovals != nil {
+			settings.MinApprovals = *update.MinApprovals
+		}
+		if update.BlockOnChangesRequested != nil {
+			settings.BlockOnChangesRequested = *update.BlockOnChangesRequested
+		}
+		if update.DefaultMaxOpenReviews != nil {
+			settings.DefaultMaxOpenReviews = *update.DefaultMaxOpenReviews
+		}
+		// A PR never gets more approvals than it has reviewers
+		if settings.MinApprovals > settings.ReviewersCount {
+			return newError(CodeInvalidRequest, "min_approvals (%d) must not exceed reviewers_count (%d)",
+				settings.MinApprovals, settings.ReviewersCount)
+		}
+
+		if err := tx.Teams().UpdateSettings(*settings); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "team.update_settings", models.AuditEntityTeam, update.TeamName, before, settings)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
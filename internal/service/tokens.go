@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// tokenPrefix starts every generated token, which makes leaked tokens easy
+// to recognize
+const tokenPrefix = "prs_"
+
+// AdminTokenName is the name of the token configured with SetAdminToken
+const AdminTokenName = "admin"
+
+// SetAdminToken configures a token with the admin scope that is not stored
+// in the database. It is meant for creating the first tokens; an empty token
+// disables it.
+func (s *Service) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// hashToken returns the form tokens are stored in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken issues a token with the given scopes. A token bound to a user
+// acts with the user's role; userID is empty for service tokens. The token is
+// returned only here; the service keeps just its hash.
+func (s *Service) CreateToken(ctx context.Context, name string, scopes []models.Scope, userID string) (*models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", newError(CodeInvalidRequest, "name is required")
+	}
+	if name == AdminTokenName {
+		return nil, "", newError(CodeInvalidRequest, "name %s is reserved", AdminTokenName)
+	}
+	if len(scopes) == 0 {
+		return nil, "", newError(CodeInvalidRequest, "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !isScope(scope) {
+			return nil, "", newError(CodeInvalidRequest, "unknown scope %q", scope)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	value := tokenPrefix + hex.EncodeToString(secret)
+
+	token := &models.APIToken{
+		Name:      name,
+		Scopes:    scopes,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+		Hash:      hashToken(value),
+	}
+	err := s.update(ctx, func(tx storage.Tx) error {
+		if userID != "" {
+			if _, err := tx.Users().Get(userID); errors.Is(err, storage.ErrNotFound) {
+				return newError(CodeNotFound, "user not found")
+			} else if err != nil {
+				return err
+			}
+		}
+
+		tokens, err := tx.Tokens().List()
+		if err != nil {
+			return err
+		}
+		for _, existing := range tokens {
+			if existing.Name == name {
+				return newError(CodeInvalidRequest, "token %s already exists", name)
+			}
+		}
+
+		if err := tx.Tokens().Create(token); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "token.create", models.AuditEntityToken, name, nil, token)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return token, value, nil
+}
+
+// ListTokens returns all stored tokens without their values
+func (s *Service) ListTokens(ctx context.Context) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		tokens, err = tx.Tokens().List()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	return tokens, nil
+}
+
+// DeleteToken revokes a token
+func (s *Service) DeleteToken(ctx context.Context, id int64) error {
+	return s.update(ctx, func(tx storage.Tx) error {
+		tokens, err := tx.Tokens().List()
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			if token.ID == id {
+				if err := tx.Tokens().Delete(id); err != nil {
+					return err
+				}
+				return recordAudit(ctx, tx, "token.delete", models.AuditEntityToken, token.Name, token, nil)
+			}
+		}
+		return newError(CodeNotFound, "token not found")
+	})
+}
+
+// Authenticate returns the caller presenting a token
+func (s *Service) Authenticate(ctx context.Context, value string) (*Caller, error) {
+	if value == "" {
+		return nil, newError(CodeUnauthorized, "bearer token is required")
+	}
+	if s.adminToken != "" && subtle.ConstantTimeCompare([]byte(value), []byte(s.adminToken)) == 1 {
+		token := models.APIToken{Name: AdminTokenName, Scopes: []models.Scope{models.ScopeAdmin}}
+		return &Caller{Token: token, Role: models.RoleAdmin}, nil
+	}
+
+	var caller *Caller
+	err := s.view(ctx, func(tx storage.Tx) error {
+		token, err := tx.Tokens().GetByHash(hashToken(value))
+		if errors.Is(err, storage.ErrNotFound) {
+			return newError(CodeUnauthorized, "invalid token")
+		}
+		if err != nil {
+			return err
+		}
+		caller, err = resolveCaller(tx, *token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return caller, nil
+}
+
+// RequireScope returns FORBIDDEN unless the token grants the scope
+func RequireScope(token *models.APIToken, scope models.Scope) error {
+	if token.Allows(scope) {
+		return nil
+	}
+	return newError(CodeForbidden, "token %s lacks the %s scope", token.Name, scope).
+		withDetail("required_scope", scope)
+}
+
+func isScope(scope models.Scope) bool {
+	for _, known := range models.Scopes {
+		if known == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Headers of webhook requests
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookBatchSize is the number of deliveries attempted per pass
+const webhookBatchSize = 100
+
+// WebhookRetryPolicy controls redelivery of webhooks that failed
+type WebhookRetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a delivery is FAILED
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; it doubles with
+	// every further attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultWebhookRetryPolicy returns the retry policy the service starts with
+func DefaultWebhookRetryPolicy() WebhookRetryPolicy {
+	return WebhookRetryPolicy{
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (p WebhookRetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// SetWebhookClient replaces the HTTP client used to deliver webhooks
+func (s *Service) SetWebhookClient(client *http.Client) {
+	s.webhookClient = client
+}
+
+// SetWebhookRetryPolicy replaces the webhook retry policy
+func (s *Service) SetWebhookRetryPolicy(policy WebhookRetryPolicy) {
+	s.webhookRetry = policy
+}
+
+// SignWebhookPayload returns the value of the signature header for a
+// payload: "sha256=" followed by the hex HMAC-SHA256 of the payload
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreateWebhook registers a URL to be notified about events. An empty event
+// list subscribes to all events.
+func (s *Service) CreateWebhook(ctx context.Context, rawURL, secret string, events []models.EventType) (*models.WebhookSubscription, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, newError(CodeInvalidRequest, "url must be an absolute http or https URL")
+	}
+	if secret == "" {
+		return nil, newError(CodeInvalidRequest, "secret is required")
+	}
+	for _, event := range events {
+		if !isEventType(event) {
+			return nil, newError(CodeInvalidRequest, "unknown event %q", event)
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}
+	if sub.Events == nil {
+		sub.Events = []models.EventType{}
+	}
+	err = s.update(ctx, func(tx storage.Tx) error {
+		if err := tx.Webhooks().CreateSubscription(sub); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, "webhook.create", models.AuditEntityWebhook, strconv.FormatInt(sub.ID, 10), nil, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListWebhooks returns all webhook subscriptions
+func (s *Service) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		subs, err = tx.Webhooks().ListSubscriptions()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if subs[i].Events == nil {
+			subs[i].Events = []models.EventType{}
+		}
+	}
+	return subs, nil
+}
+
+// DeleteWebhook removes a subscription and its pending deliveries
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	return s.update(ctx, func(tx storage.Tx) error {
+		subs, err := tx.Webhooks().ListSubscriptions()
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if sub.ID == id {
+				if err := tx.Webhooks().DeleteSubscription(id); err != nil {
+					return err
+				}
+				return recordAudit(ctx, tx, "webhook.delete", models.AuditEntityWebhook, strconv.FormatInt(id, 10), sub, nil)
+			}
+		}
+		return newError(CodeNotFound, "webhook not found")
+	})
+}
+
+// ListWebhookDeliveries returns the deliveries of a subscription, newest first
+func (s *Service) ListWebhookDeliveries(ctx context.Context, id int64) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.view(ctx, func(tx storage.Tx) error {
+		subs, err := tx.Webhooks().ListSubscriptions()
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, sub := range subs {
+			found = found || sub.ID == id
+		}
+		if !found {
+			return newError(CodeNotFound, "webhook not found")
+		}
+
+		deliveries, err = tx.Webhooks().ListDeliveries(id)
+		return err
+	})
+	return deliveries, err
+}
+
+func isEventType(eventType models.EventType) bool {
+	for _, known := range models.EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookSink publishes events by queueing a delivery for every interested
+// subscriber. The deliveries are then sent by DeliverWebhooks.
+type webhookSink struct {
+	s *Service
+}
+
+func (w webhookSink) Name() string {
+	return "webhook"
+}
+
+func (w webhookSink) Publish(ctx context.Context, event models.OutboxEvent) error {
+	return w.s.update(ctx, func(tx storage.Tx) error {
+		subs, err := tx.Webhooks().ListSubscriptions()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		for _, sub := range subs {
+			if !sub.Wants(event.EventType) {
+				continue
+			}
+			// Republished events are not queued twice for a subscriber
+			err := tx.Webhooks().EnqueueDelivery(&models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventID:        event.EventID,
+				EventType:      event.EventType,
+				Payload:        event.Payload,
+				Status:         models.DeliveryPending,
+				NextAttemptAt:  &now,
+				CreatedAt:      now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeliverWebhooks makes one attempt for every delivery that is due. Failed
+// deliveries are retried with exponential backoff until the retry policy
+// gives up. It returns the number of successful deliveries.
+func (s *Service) DeliverWebhooks(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	var due []models.WebhookDelivery
+	subs := make(map[int64]models.WebhookSubscription)
+	err := s.view(ctx, func(tx storage.Tx) error {
+		var err error
+		due, err = tx.Webhooks().DueDeliveries(now, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+		list, err := tx.Webhooks().ListSubscriptions()
+		if err != nil {
+			return err
+		}
+		for _, sub := range list {
+			subs[sub.ID] = sub
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range due {
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			continue
+		}
+
+		sendErr := s.sendWebhook(ctx, sub, delivery)
+		attemptedAt := time.Now().UTC()
+		delivery.Attempts++
+		switch {
+		case sendErr == nil:
+			delivery.Status = models.DeliveryDelivered
+			delivery.DeliveredAt = &attemptedAt
+			delivery.NextAttemptAt = nil
+			delivery.LastError = ""
+			delivered++
+		case delivery.Attempts >= s.webhookRetry.MaxAttempts:
+			delivery.Status = models.DeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.LastError = sendErr.Error()
+		default:
+			next := attemptedAt.Add(s.webhookRetry.backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+			delivery.LastError = sendErr.Error()
+		}
+
+		err := s.update(ctx, func(tx storage.Tx) error {
+			return tx.Webhooks().UpdateDelivery(delivery)
+		})
+		if err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// sendWebhook posts a signed delivery to its subscriber
+func (s *Service) sendWebhook(ctx context.Context, sub models.WebhookSubscription, delivery models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, delivery.Payload))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WatchWebhooks periodically delivers due webhooks until ctx is done
+func (s *Service) WatchWebhooks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeliverWebhooks(ctx); err != nil {
+				log.Printf("Failed to deliver webhooks: %v", err)
+			}
+		}
+	}
+}
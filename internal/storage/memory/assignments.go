@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type assignmentRepository struct {
+	t *tx
+}
+
+func (r assignmentRepository) Append(event *models.AssignmentEvent) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	event.ID = int64(len(r.t.data.history)) + 1
+	r.t.data.history = append(r.t.data.history, *event)
+	return nil
+}
+
+func (r assignmentRepository) ListByPullRequest(prID string) ([]models.AssignmentEvent, error) {
+	var events []models.AssignmentEvent
+	for _, event := range r.t.data.history {
+		if event.PullRequestID == prID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"encoding/json"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type auditRepository struct {
+	t *tx
+}
+
+func (r auditRepository) Append(entry *models.AuditEntry) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	entry.ID = int64(len(r.t.data.audit)) + 1
+	stored := *entry
+	stored.Before = copyJSON(entry.Before)
+	stored.After = copyJSON(entry.After)
+	r.t.data.audit = append(r.t.data.audit, stored)
+	return nil
+}
+
+func (r auditRepository) List(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for i := len(r.t.data.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		entry := r.t.data.audit[i]
+		if !auditMatches(entry, filter) {
+			continue
+		}
+		entry.Before = copyJSON(entry.Before)
+		entry.After = copyJSON(entry.After)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func auditMatches(entry models.AuditEntry, filter models.AuditFilter) bool {
+	switch {
+	case filter.EntityType != "" && entry.EntityType != filter.EntityType:
+		return false
+	case filter.EntityID != "" && entry.EntityID != filter.EntityID:
+		return false
+	case filter.Actor != "" && entry.Actor != filter.Actor:
+		return false
+	case filter.From != nil && entry.CreatedAt.Before(*filter.From):
+		return false
+	case filter.To != nil && !entry.CreatedAt.Before(*filter.To):
+		return false
+	case filter.BeforeID != 0 && entry.ID >= filter.BeforeID:
+		return false
+	}
+	return true
+}
+
+func copyJSON(data json.RawMessage) json.RawMessage {
+	if data == nil {
+		return nil
+	}
+	return append(json.RawMessage(nil), data...)
+}
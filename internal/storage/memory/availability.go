@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type availabilityRepository struct {
+	t *tx
+}
+
+func (r availabilityRepository) Create(window *models.AvailabilityWindow) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.nextAvailabilityID++
+	window.ID = r.t.data.nextAvailabilityID
+	r.t.data.availability[window.ID] = *window
+	return nil
+}
+
+func (r availabilityRepository) ListByUser(userID string) ([]models.AvailabilityWindow, error) {
+	return r.filter(func(w models.AvailabilityWindow) bool {
+		return w.UserID == userID
+	}), nil
+}
+
+func (r availabilityRepository) Delete(userID string, id int64) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	window, ok := r.t.data.availability[id]
+	if !ok || window.UserID != userID {
+		return storage.ErrNotFound
+	}
+	delete(r.t.data.availability, id)
+	return nil
+}
+
+func (r availabilityRepository) PendingReassignments(at time.Time) ([]models.AvailabilityWindow, error) {
+	return r.filter(func(w models.AvailabilityWindow) bool {
+		return w.ReassignOpenReviews && w.ReassignedAt == nil && w.Covers(at)
+	}), nil
+}
+
+func (r availabilityRepository) MarkReassigned(id int64, at time.Time) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	window, ok := r.t.data.availability[id]
+	if !ok || window.ReassignedAt != nil {
+		return storage.ErrNotFound
+	}
+	window.ReassignedAt = &at
+	r.t.data.availability[id] = window
+	return nil
+}
+
+// filter returns matching windows ordered by start
+func (r availabilityRepository) filter(match func(w models.AvailabilityWindow) bool) []models.AvailabilityWindow {
+	var windows []models.AvailabilityWindow
+	for _, w := range r.t.data.availability {
+		if match(w) {
+			windows = append(windows, w)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].StartsAt.Equal(windows[j].StartsAt) {
+			return windows[i].StartsAt.Before(windows[j].StartsAt)
+		}
+		return windows[i].ID < windows[j].ID
+	})
+	return windows
+}
@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type codeOwnerRepository struct {
+	t *tx
+}
+
+func (r codeOwnerRepository) List() ([]models.CodeOwnerRule, error) {
+	return copyCodeOwnerRules(r.t.data.codeOwners), nil
+}
+
+func (r codeOwnerRepository) Replace(rules []models.CodeOwnerRule) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.codeOwners = copyCodeOwnerRules(rules)
+	return nil
+}
+
+func copyCodeOwnerRules(rules []models.CodeOwnerRule) []models.CodeOwnerRule {
+	copied := make([]models.CodeOwnerRule, 0, len(rules))
+	for _, rule := range rules {
+		copied = append(copied, models.CodeOwnerRule{
+			Pattern: rule.Pattern,
+			Users:   append([]string(nil), rule.Users...),
+			Teams:   append([]string(nil), rule.Teams...),
+		})
+	}
+	return copied
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type identityRepository struct {
+	t *tx
+}
+
+func (r identityRepository) Get(provider models.IdentityProvider, login string) (*models.UserIdentity, error) {
+	userID, ok := r.t.data.identities[identityKey{provider, login}]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &models.UserIdentity{Provider: provider, Login: login, UserID: userID}, nil
+}
+
+func (r identityRepository) List() ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	for key, userID := range r.t.data.identities {
+		identities = append(identities, models.UserIdentity{Provider: key.provider, Login: key.login, UserID: userID})
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Provider != identities[j].Provider {
+			return identities[i].Provider < identities[j].Provider
+		}
+		return identities[i].Login < identities[j].Login
+	})
+	return identities, nil
+}
+
+func (r identityRepository) Upsert(identity models.UserIdentity) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.identities[identityKey{identity.Provider, identity.Login}] = identity.UserID
+	return nil
+}
+
+func (r identityRepository) Delete(provider models.IdentityProvider, login string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	key := identityKey{provider, login}
+	if _, ok := r.t.data.identities[key]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(r.t.data.identities, key)
+	return nil
+}
@@ -0,0 +1,183 @@
+// Package memory implements storage.Store in process memory. It is meant for
+// tests and local development and loses all data on restart.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Store is an in-memory storage.Store. Update transactions are serialized and
+// work on a copy of the data that replaces the original on success.
+type Store struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{data: newData()}
+}
+
+// Update runs fn in a read-write transaction. Changes are discarded if ctx
+// is done by the time fn returns.
+func (s *Store) Update(ctx context.Context, fn func(tx storage.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	working := s.data.clone()
+	if err := fn(&tx{data: working}); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.data = working
+	return nil
+}
+
+// View runs fn in a read-only transaction
+func (s *Store) View(ctx context.Context, fn func(tx storage.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn(&tx{data: s.data, readOnly: true})
+}
+
+type team struct {
+	settings  models.TeamSettings
+	cursor    string
+	fallbacks []string
+}
+
+// data is the full state of the store. Records are stored by value and
+// copied on read, so callers never share memory with the store.
+type data struct {
+	teams     map[string]team
+	users     map[string]models.User
+	prs       map[string]models.PullRequest
+	reviewers map[string][]models.Reviewer
+	// availability holds out-of-office windows by id
+	availability       map[int64]models.AvailabilityWindow
+	nextAvailabilityID int64
+	// codeOwners holds the ownership rules in file order
+	codeOwners []models.CodeOwnerRule
+	// webhooks and deliveries hold webhook subscriptions and deliveries by id
+	webhooks       map[int64]models.WebhookSubscription
+	deliveries     map[int64]models.WebhookDelivery
+	nextWebhookID  int64
+	nextDeliveryID int64
+	// outbox holds unpublished and published events by id
+	outbox       map[int64]models.OutboxEvent
+	nextOutboxID int64
+	// identities holds user ids by provider and login
+	identities map[identityKey]string
+	// tokens holds API tokens by id
+	tokens      map[int64]models.APIToken
+	nextTokenID int64
+	// audit holds the audit log in insertion order; ids start at 1
+	audit []models.AuditEntry
+	// history holds the assignment history in insertion order; ids start at 1
+	history []models.AssignmentEvent
+}
+
+type identityKey struct {
+	provider models.IdentityProvider
+	login    string
+}
+
+func newData() *data {
+	return &data{
+		teams:     make(map[string]team),
+		users:     make(map[string]models.User),
+		prs:       make(map[string]models.PullRequest),
+		reviewers: make(map[string][]models.Reviewer),
+
+		availability: make(map[int64]models.AvailabilityWindow),
+		webhooks:     make(map[int64]models.WebhookSubscription),
+		deliveries:   make(map[int64]models.WebhookDelivery),
+		outbox:       make(map[int64]models.OutboxEvent),
+		identities:   make(map[identityKey]string),
+		tokens:       make(map[int64]models.APIToken),
+	}
+}
+
+func (d *data) clone() *data {
+	c := newData()
+	for k, v := range d.teams {
+		c.teams[k] = v
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.prs {
+		c.prs[k] = v
+	}
+	for k, v := range d.reviewers {
+		c.reviewers[k] = append([]models.Reviewer(nil), v...)
+	}
+	for k, v := range d.availability {
+		c.availability[k] = v
+	}
+	c.nextAvailabilityID = d.nextAvailabilityID
+	c.codeOwners = d.codeOwners
+	for k, v := range d.webhooks {
+		c.webhooks[k] = v
+	}
+	for k, v := range d.deliveries {
+		c.deliveries[k] = v
+	}
+	c.nextWebhookID = d.nextWebhookID
+	c.nextDeliveryID = d.nextDeliveryID
+	for k, v := range d.outbox {
+		c.outbox[k] = v
+	}
+	c.nextOutboxID = d.nextOutboxID
+	for k, v := range d.identities {
+		c.identities[k] = v
+	}
+	for k, v := range d.tokens {
+		c.tokens[k] = v
+	}
+	c.nextTokenID = d.nextTokenID
+	c.audit = d.audit[:len(d.audit):len(d.audit)]
+	c.history = d.history[:len(d.history):len(d.history)]
+	return c
+}
+
+// tx implements storage.Tx over a snapshot of the data
+type tx struct {
+	data     *data
+	readOnly bool
+}
+
+func (t *tx) Teams() storage.TeamRepository                { return teamRepository{t} }
+func (t *tx) Users() storage.UserRepository                { return userRepository{t} }
+func (t *tx) PullRequests() storage.PullRequestRepository  { return pullRequestRepository{t} }
+func (t *tx) Reviewers() storage.ReviewerRepository        { return reviewerRepository{t} }
+func (t *tx) Availability() storage.AvailabilityRepository { return availabilityRepository{t} }
+func (t *tx) CodeOwners() storage.CodeOwnerRepository      { return codeOwnerRepository{t} }
+func (t *tx) Webhooks() storage.WebhookRepository          { return webhookRepository{t} }
+func (t *tx) Outbox() storage.OutboxRepository             { return outboxRepository{t} }
+func (t *tx) Identities() storage.IdentityRepository       { return identityRepository{t} }
+func (t *tx) Tokens() storage.TokenRepository              { return tokenRepository{t} }
+func (t *tx) Audit() storage.AuditRepository               { return auditRepository{t} }
+func (t *tx) Assignments() storage.AssignmentRepository    { return assignmentRepository{t} }
+func (t *tx) Stats() storage.StatsRepository               { return statsRepository{t} }
+
+func (t *tx) checkWrite() error {
+	if t.readOnly {
+		return storage.ErrReadOnly
+	}
+	return nil
+}
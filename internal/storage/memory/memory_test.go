@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		return New()
+	})
+}
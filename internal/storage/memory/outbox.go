@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type outboxRepository struct {
+	t *tx
+}
+
+func (r outboxRepository) Append(event *models.OutboxEvent) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.nextOutboxID++
+	event.ID = r.t.data.nextOutboxID
+	stored := *event
+	stored.Payload = append([]byte(nil), event.Payload...)
+	r.t.data.outbox[event.ID] = stored
+	return nil
+}
+
+func (r outboxRepository) Pending(limit int) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	for _, event := range r.t.data.outbox {
+		if event.PublishedAt == nil {
+			pending = append(pending, event)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func (r outboxRepository) MarkPublished(id int64, at time.Time) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	event, ok := r.t.data.outbox[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	event.PublishedAt = &at
+	r.t.data.outbox[id] = event
+	return nil
+}
+
+func (r outboxRepository) RecordFailure(id int64, lastError string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	event, ok := r.t.data.outbox[id]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	event.Attempts++
+	event.LastError = lastError
+	r.t.data.outbox[id] = event
+	return nil
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type pullRequestRepository struct {
+	t *tx
+}
+
+func (r pullRequestRepository) Create(pr models.PullRequest) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	pr.AssignedReviewers = nil
+	pr.ChangedFiles = append([]string(nil), pr.ChangedFiles...)
+	r.t.data.prs[pr.PullRequestID] = pr
+	return nil
+}
+
+func (r pullRequestRepository) Get(prID string) (*models.PullRequest, error) {
+	pr, ok := r.t.data.prs[prID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	pr.ChangedFiles = append([]string(nil), pr.ChangedFiles...)
+	return &pr, nil
+}
+
+func (r pullRequestRepository) Update(pr models.PullRequest) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	stored, ok := r.t.data.prs[pr.PullRequestID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	stored.Status = pr.Status
+	stored.AssignmentStrategy = pr.AssignmentStrategy
+	stored.ForceMerged = pr.ForceMerged
+	stored.Understaffed = pr.Understaffed
+	stored.MergedAt = pr.MergedAt
+	stored.ClosedAt = pr.ClosedAt
+	r.t.data.prs[pr.PullRequestID] = stored
+	return nil
+}
+
+func (r pullRequestRepository) ListByReviewer(userID string) ([]models.PullRequestShort, error) {
+	var prs []models.PullRequest
+	for prID, reviewers := range r.t.data.reviewers {
+		for _, reviewer := range reviewers {
+			if reviewer.UserID == userID {
+				prs = append(prs, r.t.data.prs[prID])
+				break
+			}
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		a, b := timeOrZero(prs[i].CreatedAt), timeOrZero(prs[j].CreatedAt)
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return prs[i].PullRequestID < prs[j].PullRequestID
+	})
+
+	var shorts []models.PullRequestShort
+	for _, pr := range prs {
+		shorts = append(shorts, models.PullRequestShort{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+		})
+	}
+	return shorts, nil
+}
+
+func (r pullRequestRepository) List(filter models.PullRequestFilter) ([]models.PullRequestShort, error) {
+	var prs []models.PullRequest
+	for _, pr := range r.t.data.prs {
+		if r.matches(pr, filter) {
+			prs = append(prs, pr)
+		}
+	}
+	oldestFirst := filter.Sort == models.SortOldestFirst
+	sort.Slice(prs, func(i, j int) bool {
+		return comesBefore(prKey(prs[i]), prKey(prs[j]), oldestFirst)
+	})
+
+	var shorts []models.PullRequestShort
+	for _, pr := range prs {
+		if filter.After != nil && !comesBefore(*filter.After, prKey(pr), oldestFirst) {
+			continue
+		}
+		if len(shorts) == filter.Limit {
+			break
+		}
+		createdAt := timeOrZero(pr.CreatedAt)
+		shorts = append(shorts, models.PullRequestShort{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+			CreatedAt:       &createdAt,
+		})
+	}
+	return shorts, nil
+}
+
+// matches applies the filter to a pull request, except its After key
+func (r pullRequestRepository) matches(pr models.PullRequest, filter models.PullRequestFilter) bool {
+	if filter.ReviewerID != "" {
+		assigned := false
+		for _, reviewer := range r.t.data.reviewers[pr.PullRequestID] {
+			assigned = assigned || reviewer.UserID == filter.ReviewerID
+		}
+		if !assigned {
+			return false
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			found = found || pr.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.AuthorID != "" && pr.AuthorID != filter.AuthorID {
+		return false
+	}
+	if filter.TeamName != "" && r.t.data.users[pr.AuthorID].TeamName != filter.TeamName {
+		return false
+	}
+	createdAt := timeOrZero(pr.CreatedAt)
+	if filter.From != nil && createdAt.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && !createdAt.Before(*filter.To) {
+		return false
+	}
+	return true
+}
+
+func prKey(pr models.PullRequest) models.PullRequestKey {
+	return models.PullRequestKey{CreatedAt: timeOrZero(pr.CreatedAt), PullRequestID: pr.PullRequestID}
+}
+
+// comesBefore reports whether a pull request at a is listed before one at b
+func comesBefore(a, b models.PullRequestKey, oldestFirst bool) bool {
+	if !oldestFirst {
+		a, b = b, a
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.PullRequestID < b.PullRequestID
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
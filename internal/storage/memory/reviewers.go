@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type reviewerRepository struct {
+	t *tx
+}
+
+func (r reviewerRepository) List(prID string) ([]models.Reviewer, error) {
+	reviewers := append([]models.Reviewer(nil), r.t.data.reviewers[prID]...)
+	sort.Slice(reviewers, func(i, j int) bool {
+		return reviewers[i].UserID < reviewers[j].UserID
+	})
+	return reviewers, nil
+}
+
+func (r reviewerRepository) Add(prID string, reviewer models.Reviewer) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.reviewers[prID] = append(r.t.data.reviewers[prID], reviewer)
+	return nil
+}
+
+func (r reviewerRepository) Replace(prID, oldUserID string, reviewer models.Reviewer) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	reviewers := r.t.data.reviewers[prID]
+	for i := range reviewers {
+		if reviewers[i].UserID == oldUserID {
+			reviewers[i] = reviewer
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (r reviewerRepository) SetVerdict(prID, userID string, state models.ReviewState, reviewedAt time.Time) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	reviewers := r.t.data.reviewers[prID]
+	for i := range reviewers {
+		if reviewers[i].UserID == userID {
+			reviewers[i].State = state
+			reviewers[i].ReviewedAt = &reviewedAt
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
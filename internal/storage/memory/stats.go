@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type statsRepository struct {
+	t *tx
+}
+
+func (r statsRepository) UserAssignments() ([]models.UserAssignmentStats, error) {
+	byUser := make(map[string]*models.UserAssignmentStats, len(r.t.data.users))
+	for _, user := range r.t.data.users {
+		byUser[user.UserID] = &models.UserAssignmentStats{UserID: user.UserID, Username: user.Username}
+	}
+	for _, event := range r.t.data.history {
+		if event.Action == models.AssignmentRemoved {
+			continue
+		}
+		if stat, ok := byUser[event.ReviewerID]; ok {
+			stat.TotalAssignments++
+		}
+		if stat, ok := byUser[event.PreviousReviewerID]; ok && event.Action == models.AssignmentReassigned {
+			stat.ReassignedAway++
+		}
+	}
+	for prID, reviewers := range r.t.data.reviewers {
+		status := r.t.data.prs[prID].Status
+		for _, reviewer := range reviewers {
+			stat, ok := byUser[reviewer.UserID]
+			if !ok {
+				continue
+			}
+			switch status {
+			case models.StatusOpen:
+				stat.OpenPRs++
+			case models.StatusMerged:
+				stat.MergedPRs++
+			}
+		}
+	}
+
+	var stats []models.UserAssignmentStats
+	for _, stat := range byUser {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalAssignments != stats[j].TotalAssignments {
+			return stats[i].TotalAssignments > stats[j].TotalAssignments
+		}
+		return stats[i].Username < stats[j].Username
+	})
+	return stats, nil
+}
+
+func (r statsRepository) PullRequests() (models.PRStatistics, error) {
+	var stats models.PRStatistics
+	for prID, pr := range r.t.data.prs {
+		stats.TotalPRs++
+		switch pr.Status {
+		case models.StatusOpen:
+			stats.OpenPRs++
+		case models.StatusMerged:
+			stats.MergedPRs++
+		case models.StatusClosed:
+			stats.ClosedPRs++
+		case models.StatusDraft:
+			stats.DraftPRs++
+		}
+		if len(r.t.data.reviewers[prID]) > 0 {
+			stats.PRsWithReviewers++
+		} else {
+			stats.PRsWithoutReviewers++
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type teamRepository struct {
+	t *tx
+}
+
+func (r teamRepository) Create(teamName string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.teams[teamName] = team{settings: storage.DefaultTeamSettings(teamName)}
+	return nil
+}
+
+func (r teamRepository) Get(teamName string) (*models.TeamSettings, error) {
+	team, ok := r.t.data.teams[teamName]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	settings := team.settings
+	return &settings, nil
+}
+
+func (r teamRepository) UpdateSettings(settings models.TeamSettings) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	team, ok := r.t.data.teams[settings.TeamName]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	team.settings = settings
+	r.t.data.teams[settings.TeamName] = team
+	return nil
+}
+
+func (r teamRepository) RoundRobinCursor(teamName string) (string, error) {
+	team, ok := r.t.data.teams[teamName]
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+	return team.cursor, nil
+}
+
+func (r teamRepository) SetRoundRobinCursor(teamName, userID string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	team, ok := r.t.data.teams[teamName]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	team.cursor = userID
+	r.t.data.teams[teamName] = team
+	return nil
+}
+
+func (r teamRepository) FallbackTeams(teamName string) ([]string, error) {
+	team, ok := r.t.data.teams[teamName]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return append([]string(nil), team.fallbacks...), nil
+}
+
+func (r teamRepository) SetFallbackTeams(teamName string, fallbackTeams []string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	team, ok := r.t.data.teams[teamName]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	team.fallbacks = append([]string(nil), fallbackTeams...)
+	r.t.data.teams[teamName] = team
+	return nil
+}
+
+func (r teamRepository) List(filter models.TeamFilter) ([]models.TeamSummary, error) {
+	var names []string
+	for name := range r.t.data.teams {
+		if hasPrefixFold(name, filter.NamePrefix) && name > filter.After {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > filter.Limit {
+		names = names[:filter.Limit]
+	}
+
+	var teams []models.TeamSummary
+	for _, name := range names {
+		team := models.TeamSummary{TeamName: name}
+		for _, user := range r.t.data.users {
+			if user.TeamName == name {
+				team.MemberCount++
+				if user.IsActive {
+					team.ActiveMembers++
+				}
+			}
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+func (r teamRepository) Rename(teamName, newName string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	renamed, ok := r.t.data.teams[teamName]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	renamed.settings.TeamName = newName
+	delete(r.t.data.teams, teamName)
+	r.t.data.teams[newName] = renamed
+
+	r.replaceFallback(teamName, newName)
+	for id, user := range r.t.data.users {
+		if user.TeamName == teamName {
+			user.TeamName = newName
+			r.t.data.users[id] = user
+		}
+	}
+	for _, reviewers := range r.t.data.reviewers {
+		for i := range reviewers {
+			if reviewers[i].FallbackTeam == teamName {
+				reviewers[i].FallbackTeam = newName
+			}
+		}
+	}
+	return nil
+}
+
+func (r teamRepository) Delete(teamName string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	if _, ok := r.t.data.teams[teamName]; !ok {
+		return storage.ErrNotFound
+	}
+	for _, user := range r.t.data.users {
+		if user.TeamName == teamName {
+			return fmt.Errorf("memory: team %s still has members", teamName)
+		}
+	}
+	delete(r.t.data.teams, teamName)
+	r.replaceFallback(teamName, "")
+	return nil
+}
+
+// replaceFallback renames a fallback team in the lists of every team; ""
+// drops it. Lists are shared with snapshots, so they are copied, not changed.
+func (r teamRepository) replaceFallback(teamName, newName string) {
+	for name, team := range r.t.data.teams {
+		var fallbacks []string
+		for _, fallback := range team.fallbacks {
+			switch {
+			case fallback != teamName:
+				fallbacks = append(fallbacks, fallback)
+			case newName != "":
+				fallbacks = append(fallbacks, newName)
+			}
+		}
+		team.fallbacks = fallbacks
+		r.t.data.teams[name] = team
+	}
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring case
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type tokenRepository struct {
+	t *tx
+}
+
+func (r tokenRepository) Create(token *models.APIToken) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.nextTokenID++
+	token.ID = r.t.data.nextTokenID
+	stored := *token
+	stored.Scopes = append([]models.Scope(nil), token.Scopes...)
+	r.t.data.tokens[token.ID] = stored
+	return nil
+}
+
+func (r tokenRepository) GetByHash(hash string) (*models.APIToken, error) {
+	for _, token := range r.t.data.tokens {
+		if token.Hash == hash {
+			token.Scopes = append([]models.Scope(nil), token.Scopes...)
+			return &token, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (r tokenRepository) List() ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	for _, token := range r.t.data.tokens {
+		token.Scopes = append([]models.Scope(nil), token.Scopes...)
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+func (r tokenRepository) Delete(id int64) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	if _, ok := r.t.data.tokens[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(r.t.data.tokens, id)
+	return nil
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type userRepository struct {
+	t *tx
+}
+
+func (r userRepository) Upsert(user models.User) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.users[user.UserID] = user
+	return nil
+}
+
+func (r userRepository) Get(userID string) (*models.User, error) {
+	user, ok := r.t.data.users[userID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r userRepository) ListByTeam(teamName string) ([]models.User, error) {
+	var users []models.User
+	for _, user := range r.t.data.users {
+		if user.TeamName == teamName {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	return users, nil
+}
+
+func (r userRepository) List(filter models.UserFilter) ([]models.User, error) {
+	var users []models.User
+	for _, user := range r.t.data.users {
+		switch {
+		case filter.TeamName != "" && user.TeamName != filter.TeamName:
+		case filter.IsActive != nil && user.IsActive != *filter.IsActive:
+		case !hasPrefixFold(user.Username, filter.NamePrefix):
+		case user.UserID <= filter.After:
+		default:
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	if len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
+func (r userRepository) SetActive(userID string, isActive bool) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	user, ok := r.t.data.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	user.IsActive = isActive
+	r.t.data.users[userID] = user
+	return nil
+}
+
+func (r userRepository) SetMaxOpenReviews(userID string, limit *int) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	user, ok := r.t.data.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	user.MaxOpenReviews = limit
+	r.t.data.users[userID] = user
+	return nil
+}
+
+func (r userRepository) SetRole(userID string, role models.Role) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	user, ok := r.t.data.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	user.Role = role
+	r.t.data.users[userID] = user
+	return nil
+}
+
+func (r userRepository) SetTeam(userID, teamName string) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	user, ok := r.t.data.users[userID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	user.TeamName = teamName
+	r.t.data.users[userID] = user
+	return nil
+}
+
+func (r userRepository) ActiveLoads(teamName string, excludeIDs []string, at time.Time) ([]storage.ReviewerLoad, error) {
+	excluded := make(map[string]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+	for _, window := range r.t.data.availability {
+		if window.Covers(at) {
+			excluded[window.UserID] = true
+		}
+	}
+
+	members, _ := r.ListByTeam(teamName)
+	var loads []storage.ReviewerLoad
+	for _, user := range members {
+		if !user.IsActive || excluded[user.UserID] {
+			continue
+		}
+		load := storage.ReviewerLoad{UserID: user.UserID, MaxOpenReviews: r.t.data.teams[teamName].settings.DefaultMaxOpenReviews}
+		if user.MaxOpenReviews != nil {
+			load.MaxOpenReviews = *user.MaxOpenReviews
+		}
+		for prID, reviewers := range r.t.data.reviewers {
+			for _, reviewer := range reviewers {
+				if reviewer.UserID != user.UserID {
+					continue
+				}
+				if r.t.data.prs[prID].Status == models.StatusOpen {
+					load.OpenReviews++
+				}
+				if reviewer.AssignedAt != nil && (load.LastAssignedAt == nil || reviewer.AssignedAt.After(*load.LastAssignedAt)) {
+					assignedAt := *reviewer.AssignedAt
+					load.LastAssignedAt = &assignedAt
+				}
+			}
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type webhookRepository struct {
+	t *tx
+}
+
+func (r webhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	r.t.data.nextWebhookID++
+	sub.ID = r.t.data.nextWebhookID
+	stored := *sub
+	stored.Events = append([]models.EventType(nil), sub.Events...)
+	r.t.data.webhooks[sub.ID] = stored
+	return nil
+}
+
+func (r webhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	for _, sub := range r.t.data.webhooks {
+		sub.Events = append([]models.EventType(nil), sub.Events...)
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID < subs[j].ID
+	})
+	return subs, nil
+}
+
+func (r webhookRepository) DeleteSubscription(id int64) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	if _, ok := r.t.data.webhooks[id]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(r.t.data.webhooks, id)
+	for deliveryID, delivery := range r.t.data.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(r.t.data.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (r webhookRepository) EnqueueDelivery(delivery *models.WebhookDelivery) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	for _, existing := range r.t.data.deliveries {
+		if existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID {
+			delivery.ID = existing.ID
+			return nil
+		}
+	}
+	r.t.data.nextDeliveryID++
+	delivery.ID = r.t.data.nextDeliveryID
+	stored := *delivery
+	stored.Payload = append([]byte(nil), delivery.Payload...)
+	r.t.data.deliveries[delivery.ID] = stored
+	return nil
+}
+
+func (r webhookRepository) DueDeliveries(at time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	for _, delivery := range r.t.data.deliveries {
+		if delivery.Status == models.DeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(at) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, b := *due[i].NextAttemptAt, *due[j].NextAttemptAt
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r webhookRepository) ListDeliveries(subscriptionID int64) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.t.data.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	return deliveries, nil
+}
+
+func (r webhookRepository) UpdateDelivery(delivery models.WebhookDelivery) error {
+	if err := r.t.checkWrite(); err != nil {
+		return err
+	}
+	stored, ok := r.t.data.deliveries[delivery.ID]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastError = delivery.LastError
+	stored.DeliveredAt = delivery.DeliveredAt
+	r.t.data.deliveries[delivery.ID] = stored
+	return nil
+}
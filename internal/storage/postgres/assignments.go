@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type assignmentRepository struct {
+	t *tx
+}
+
+func (r assignmentRepository) Append(event *models.AssignmentEvent) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO assignment_history (pull_request_id, action, reviewer_id, previous_reviewer_id, fallback_team, reason, actor, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, event.PullRequestID, event.Action, event.ReviewerID, nullString(event.PreviousReviewerID), nullString(event.FallbackTeam),
+		event.Reason, event.Actor, event.OccurredAt).Scan(&event.ID)
+}
+
+func (r assignmentRepository) ListByPullRequest(prID string) ([]models.AssignmentEvent, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT id, pull_request_id, action, reviewer_id, previous_reviewer_id, fallback_team, reason, actor, occurred_at
+		FROM assignment_history
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AssignmentEvent
+	for rows.Next() {
+		var e models.AssignmentEvent
+		var previous, fallbackTeam sql.NullString
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.Action, &e.ReviewerID, &previous, &fallbackTeam, &e.Reason, &e.Actor, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		e.PreviousReviewerID, e.FallbackTeam = previous.String, fallbackTeam.String
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type auditRepository struct {
+	t *tx
+}
+
+func (r auditRepository) Append(entry *models.AuditEntry) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, entry.Actor, entry.Action, entry.EntityType, entry.EntityID, nullJSON(entry.Before), nullJSON(entry.After),
+		entry.CreatedAt).Scan(&entry.ID)
+}
+
+func (r auditRepository) List(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.EntityType != "" {
+		where("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		where("entity_id = $%d", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+	if filter.BeforeID != 0 {
+		where("id < $%d", filter.BeforeID)
+	}
+
+	query := "SELECT id, actor, action, entity_type, entity_id, before, after, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.EntityType, &e.EntityID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// nullJSON converts an empty JSON document to NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
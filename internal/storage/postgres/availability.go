@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type availabilityRepository struct {
+	t *tx
+}
+
+func (r availabilityRepository) Create(window *models.AvailabilityWindow) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO user_availability (user_id, starts_at, ends_at, reason, reassign_open_reviews, reassigned_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, window.UserID, window.StartsAt, window.EndsAt, window.Reason, window.ReassignOpenReviews, window.ReassignedAt).Scan(&window.ID)
+}
+
+func (r availabilityRepository) ListByUser(userID string) ([]models.AvailabilityWindow, error) {
+	return r.query(`
+		SELECT id, user_id, starts_at, ends_at, reason, reassign_open_reviews, reassigned_at
+		FROM user_availability
+		WHERE user_id = $1
+		ORDER BY starts_at, id
+	`, userID)
+}
+
+func (r availabilityRepository) Delete(userID string, id int64) error {
+	return r.t.exec(true, "DELETE FROM user_availability WHERE user_id = $1 AND id = $2", userID, id)
+}
+
+func (r availabilityRepository) PendingReassignments(at time.Time) ([]models.AvailabilityWindow, error) {
+	return r.query(`
+		SELECT id, user_id, starts_at, ends_at, reason, reassign_open_reviews, reassigned_at
+		FROM user_availability
+		WHERE reassign_open_reviews = true AND reassigned_at IS NULL AND starts_at <= $1 AND ends_at > $1
+		ORDER BY starts_at, id
+	`, at)
+}
+
+func (r availabilityRepository) MarkReassigned(id int64, at time.Time) error {
+	return r.t.exec(true, "UPDATE user_availability SET reassigned_at = $1 WHERE id = $2 AND reassigned_at IS NULL", at, id)
+}
+
+func (r availabilityRepository) query(query string, args ...interface{}) ([]models.AvailabilityWindow, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []models.AvailabilityWindow
+	for rows.Next() {
+		var w models.AvailabilityWindow
+		if err := rows.Scan(&w.ID, &w.UserID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.ReassignOpenReviews, &w.ReassignedAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
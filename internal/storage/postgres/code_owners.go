@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/lib/pq"
+)
+
+type codeOwnerRepository struct {
+	t *tx
+}
+
+func (r codeOwnerRepository) List() ([]models.CodeOwnerRule, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT pattern, owner_users, owner_teams
+		FROM code_owner_rules
+		ORDER BY position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.CodeOwnerRule
+	for rows.Next() {
+		var rule models.CodeOwnerRule
+		if err := rows.Scan(&rule.Pattern, pq.Array(&rule.Users), pq.Array(&rule.Teams)); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r codeOwnerRepository) Replace(rules []models.CodeOwnerRule) error {
+	if err := r.t.exec(false, "DELETE FROM code_owner_rules"); err != nil {
+		return err
+	}
+	for i, rule := range rules {
+		err := r.t.exec(false, `
+			INSERT INTO code_owner_rules (position, pattern, owner_users, owner_teams)
+			VALUES ($1, $2, $3, $4)
+		`, i, rule.Pattern, pq.Array(nonNil(rule.Users)), pq.Array(nonNil(rule.Teams)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nonNil turns a nil slice into an empty one so that it is stored as '{}'
+// rather than NULL
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
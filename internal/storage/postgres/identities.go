@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type identityRepository struct {
+	t *tx
+}
+
+func (r identityRepository) Get(provider models.IdentityProvider, login string) (*models.UserIdentity, error) {
+	identity := models.UserIdentity{Provider: provider, Login: login}
+	err := r.t.tx.QueryRowContext(r.t.ctx, `
+		SELECT user_id
+		FROM user_identities
+		WHERE provider = $1 AND login = $2
+	`, provider, login).Scan(&identity.UserID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &identity, nil
+}
+
+func (r identityRepository) List() ([]models.UserIdentity, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT provider, login, user_id
+		FROM user_identities
+		ORDER BY provider, login
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []models.UserIdentity
+	for rows.Next() {
+		var identity models.UserIdentity
+		if err := rows.Scan(&identity.Provider, &identity.Login, &identity.UserID); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+func (r identityRepository) Upsert(identity models.UserIdentity) error {
+	return r.t.exec(false, `
+		INSERT INTO user_identities (provider, login, user_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id
+	`, identity.Provider, identity.Login, identity.UserID)
+}
+
+func (r identityRepository) Delete(provider models.IdentityProvider, login string) error {
+	return r.t.exec(true, "DELETE FROM user_identities WHERE provider = $1 AND login = $2", provider, login)
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type outboxRepository struct {
+	t *tx
+}
+
+func (r outboxRepository) Append(event *models.OutboxEvent) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO outbox_events (event_id, event_type, payload, occurred_at, published_at, attempts, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, event.EventID, event.EventType, string(event.Payload), event.OccurredAt, event.PublishedAt,
+		event.Attempts, event.LastError).Scan(&event.ID)
+}
+
+func (r outboxRepository) Pending(limit int) ([]models.OutboxEvent, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT id, event_id, event_type, payload, occurred_at, published_at, attempts, last_error
+		FROM outbox_events
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var e models.OutboxEvent
+		err := rows.Scan(&e.ID, &e.EventID, &e.EventType, &e.Payload, &e.OccurredAt, &e.PublishedAt, &e.Attempts, &e.LastError)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r outboxRepository) MarkPublished(id int64, at time.Time) error {
+	return r.t.exec(true, "UPDATE outbox_events SET published_at = $1 WHERE id = $2", at, id)
+}
+
+func (r outboxRepository) RecordFailure(id int64, lastError string) error {
+	return r.t.exec(true, "UPDATE outbox_events SET attempts = attempts + 1, last_error = $1 WHERE id = $2", lastError, id)
+}
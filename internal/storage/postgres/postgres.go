@@ -0,0 +1,104 @@
+// Package postgres implements storage.Store on top of PostgreSQL
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Store is a PostgreSQL-backed storage.Store. The schema is managed by the
+// migrations in the database package.
+type Store struct {
+	db *sql.DB
+}
+
+// New creates a store over an open database connection
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Update runs fn in a read-write transaction
+func (s *Store) Update(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return s.run(ctx, false, fn)
+}
+
+// View runs fn in a read-only transaction
+func (s *Store) View(ctx context.Context, fn func(tx storage.Tx) error) error {
+	return s.run(ctx, true, fn)
+}
+
+func (s *Store) run(ctx context.Context, readOnly bool, fn func(tx storage.Tx) error) error {
+	sqlTx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(&tx{ctx: ctx, tx: sqlTx, readOnly: readOnly}); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+// tx implements storage.Tx over a database transaction
+type tx struct {
+	ctx      context.Context
+	tx       *sql.Tx
+	readOnly bool
+}
+
+func (t *tx) Teams() storage.TeamRepository                { return teamRepository{t} }
+func (t *tx) Users() storage.UserRepository                { return userRepository{t} }
+func (t *tx) PullRequests() storage.PullRequestRepository  { return pullRequestRepository{t} }
+func (t *tx) Reviewers() storage.ReviewerRepository        { return reviewerRepository{t} }
+func (t *tx) Availability() storage.AvailabilityRepository { return availabilityRepository{t} }
+func (t *tx) CodeOwners() storage.CodeOwnerRepository      { return codeOwnerRepository{t} }
+func (t *tx) Webhooks() storage.WebhookRepository          { return webhookRepository{t} }
+func (t *tx) Outbox() storage.OutboxRepository             { return outboxRepository{t} }
+func (t *tx) Identities() storage.IdentityRepository       { return identityRepository{t} }
+func (t *tx) Tokens() storage.TokenRepository              { return tokenRepository{t} }
+func (t *tx) Audit() storage.AuditRepository               { return auditRepository{t} }
+func (t *tx) Assignments() storage.AssignmentRepository    { return assignmentRepository{t} }
+func (t *tx) Stats() storage.StatsRepository               { return statsRepository{t} }
+
+// exec runs a write statement and reports storage.ErrNotFound if it
+// affected no rows and mustAffect is set
+func (t *tx) exec(mustAffect bool, query string, args ...interface{}) error {
+	if t.readOnly {
+		return storage.ErrReadOnly
+	}
+
+	result, err := t.tx.ExecContext(t.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if !mustAffect {
+		return nil
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// notFound translates sql.ErrNoRows into storage.ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return storage.ErrNotFound
+	}
+	return err
+}
+
+// likePrefix returns a LIKE pattern matching strings that start with prefix
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
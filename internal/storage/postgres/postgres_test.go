@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/database"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := database.NewDB(connStr)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storage.Store {
+		for _, table := range []string{"api_tokens", "user_identities", "outbox_events", "webhook_deliveries", "webhook_subscriptions", "code_owner_rules", "user_availability", "team_fallbacks", "assignment_history", "pr_reviewers", "pull_requests", "users", "teams"} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				t.Fatalf("Failed to clean %s: %v", table, err)
+			}
+		}
+		// The audit log rejects DELETE; TRUNCATE bypasses its row trigger
+		if _, err := db.Exec("TRUNCATE audit_log RESTART IDENTITY"); err != nil {
+			t.Fatalf("Failed to clean audit_log: %v", err)
+		}
+		return New(db.DB)
+	})
+}
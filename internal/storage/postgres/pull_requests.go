@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/lib/pq"
+)
+
+type pullRequestRepository struct {
+	t *tx
+}
+
+func (r pullRequestRepository) Create(pr models.PullRequest) error {
+	return r.t.exec(false, `
+		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, assignment_strategy, force_merged, understaffed, reviewers_count, changed_files, created_at, merged_at, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, nullStrategy(pr.AssignmentStrategy), pr.ForceMerged, pr.Understaffed, pr.ReviewersCount, pq.Array(nonNil(pr.ChangedFiles)), pr.CreatedAt, pr.MergedAt, pr.ClosedAt)
+}
+
+func (r pullRequestRepository) Get(prID string) (*models.PullRequest, error) {
+	query := `
+		SELECT pull_request_id, pull_request_name, author_id, status, assignment_strategy, force_merged, understaffed, reviewers_count, changed_files, created_at, merged_at, closed_at
+		FROM pull_requests
+		WHERE pull_request_id = $1
+	`
+	if !r.t.readOnly {
+		query += " FOR UPDATE"
+	}
+
+	var pr models.PullRequest
+	var strategy sql.NullString
+	err := r.t.tx.QueryRowContext(r.t.ctx, query, prID).Scan(
+		&pr.PullRequestID,
+		&pr.PullRequestName,
+		&pr.AuthorID,
+		&pr.Status,
+		&strategy,
+		&pr.ForceMerged,
+		&pr.Understaffed,
+		&pr.ReviewersCount,
+		pq.Array(&pr.ChangedFiles),
+		&pr.CreatedAt,
+		&pr.MergedAt,
+		&pr.ClosedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	pr.AssignmentStrategy = models.SelectionStrategy(strategy.String)
+
+	return &pr, nil
+}
+
+func (r pullRequestRepository) Update(pr models.PullRequest) error {
+	return r.t.exec(true, `
+		UPDATE pull_requests
+		SET status = $1, assignment_strategy = $2, force_merged = $3, understaffed = $4, merged_at = $5, closed_at = $6
+		WHERE pull_request_id = $7
+	`, pr.Status, nullStrategy(pr.AssignmentStrategy), pr.ForceMerged, pr.Understaffed, pr.MergedAt, pr.ClosedAt, pr.PullRequestID)
+}
+
+func (r pullRequestRepository) ListByReviewer(userID string) ([]models.PullRequestShort, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+		FROM pull_requests pr
+		INNER JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
+		WHERE prr.reviewer_id = $1
+		ORDER BY pr.created_at DESC, pr.pull_request_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.PullRequestShort
+	for rows.Next() {
+		var pr models.PullRequestShort
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
+}
+
+func (r pullRequestRepository) List(filter models.PullRequestFilter) ([]models.PullRequestShort, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ReviewerID != "" {
+		where("EXISTS (SELECT 1 FROM pr_reviewers prr WHERE prr.pull_request_id = pr.pull_request_id AND prr.reviewer_id = $%d)", filter.ReviewerID)
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		where("pr.status = ANY($%d)", pq.Array(statuses))
+	}
+	if filter.AuthorID != "" {
+		where("pr.author_id = $%d", filter.AuthorID)
+	}
+	if filter.TeamName != "" {
+		where("pr.author_id IN (SELECT user_id FROM users WHERE team_name = $%d)", filter.TeamName)
+	}
+	if filter.From != nil {
+		where("pr.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("pr.created_at < $%d", *filter.To)
+	}
+
+	order, after := "DESC", "<"
+	if filter.Sort == models.SortOldestFirst {
+		order, after = "ASC", ">"
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt, filter.After.PullRequestID)
+		conditions = append(conditions, fmt.Sprintf("(pr.created_at, pr.pull_request_id) %s ($%d, $%d)", after, len(args)-1, len(args)))
+	}
+
+	query := "SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at FROM pull_requests pr"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY pr.created_at %s, pr.pull_request_id %s LIMIT $%d", order, order, len(args))
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []models.PullRequestShort
+	for rows.Next() {
+		var pr models.PullRequestShort
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt); err != nil {
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, rows.Err()
+}
+
+func nullStrategy(strategy models.SelectionStrategy) sql.NullString {
+	return sql.NullString{String: string(strategy), Valid: strategy != ""}
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type reviewerRepository struct {
+	t *tx
+}
+
+func (r reviewerRepository) List(prID string) ([]models.Reviewer, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT reviewer_id, state, assigned_at, reviewed_at, fallback_team
+		FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY reviewer_id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviewers []models.Reviewer
+	for rows.Next() {
+		var reviewer models.Reviewer
+		var fallbackTeam sql.NullString
+		if err := rows.Scan(&reviewer.UserID, &reviewer.State, &reviewer.AssignedAt, &reviewer.ReviewedAt, &fallbackTeam); err != nil {
+			return nil, err
+		}
+		reviewer.FallbackTeam = fallbackTeam.String
+		reviewers = append(reviewers, reviewer)
+	}
+	return reviewers, rows.Err()
+}
+
+func (r reviewerRepository) Add(prID string, reviewer models.Reviewer) error {
+	return r.t.exec(false, `
+		INSERT INTO pr_reviewers (pull_request_id, reviewer_id, state, assigned_at, reviewed_at, fallback_team)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, prID, reviewer.UserID, reviewer.State, reviewer.AssignedAt, reviewer.ReviewedAt, nullString(reviewer.FallbackTeam))
+}
+
+func (r reviewerRepository) Replace(prID, oldUserID string, reviewer models.Reviewer) error {
+	return r.t.exec(true, `
+		UPDATE pr_reviewers
+		SET reviewer_id = $1, state = $2, assigned_at = $3, reviewed_at = $4, fallback_team = $5
+		WHERE pull_request_id = $6 AND reviewer_id = $7
+	`, reviewer.UserID, reviewer.State, reviewer.AssignedAt, reviewer.ReviewedAt, nullString(reviewer.FallbackTeam), prID, oldUserID)
+}
+
+func (r reviewerRepository) SetVerdict(prID, userID string, state models.ReviewState, reviewedAt time.Time) error {
+	return r.t.exec(true, `
+		UPDATE pr_reviewers
+		SET state = $1, reviewed_at = $2
+		WHERE pull_request_id = $3 AND reviewer_id = $4
+	`, state, reviewedAt, prID, userID)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
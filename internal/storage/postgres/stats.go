@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type statsRepository struct {
+	t *tx
+}
+
+func (r statsRepository) UserAssignments() ([]models.UserAssignmentStats, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT
+			u.user_id,
+			u.username,
+			(SELECT COUNT(*) FROM assignment_history h
+			 WHERE h.reviewer_id = u.user_id AND h.action IN ('ASSIGNED', 'REASSIGNED')) as total_assignments,
+			(SELECT COUNT(*) FROM assignment_history h
+			 WHERE h.previous_reviewer_id = u.user_id AND h.action = 'REASSIGNED') as reassigned_away,
+			COUNT(CASE WHEN pr.status = 'OPEN' THEN 1 END) as open_prs,
+			COUNT(CASE WHEN pr.status = 'MERGED' THEN 1 END) as merged_prs
+		FROM users u
+		LEFT JOIN pr_reviewers prr ON u.user_id = prr.reviewer_id
+		LEFT JOIN pull_requests pr ON prr.pull_request_id = pr.pull_request_id
+		GROUP BY u.user_id, u.username
+		ORDER BY total_assignments DESC, u.username
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.UserAssignmentStats
+	for rows.Next() {
+		var stat models.UserAssignmentStats
+		if err := rows.Scan(&stat.UserID, &stat.Username, &stat.TotalAssignments, &stat.ReassignedAway, &stat.OpenPRs, &stat.MergedPRs); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+func (r statsRepository) PullRequests() (models.PRStatistics, error) {
+	var stats models.PRStatistics
+	err := r.t.tx.QueryRowContext(r.t.ctx, `
+		SELECT
+			COUNT(*) as total_prs,
+			COUNT(CASE WHEN status = 'OPEN' THEN 1 END) as open_prs,
+			COUNT(CASE WHEN status = 'MERGED' THEN 1 END) as merged_prs,
+			COUNT(CASE WHEN status = 'CLOSED' THEN 1 END) as closed_prs,
+			COUNT(CASE WHEN status = 'DRAFT' THEN 1 END) as draft_prs,
+			COUNT(CASE WHEN EXISTS(SELECT 1 FROM pr_reviewers WHERE pull_request_id = pr.pull_request_id) THEN 1 END) as prs_with_reviewers,
+			COUNT(CASE WHEN NOT EXISTS(SELECT 1 FROM pr_reviewers WHERE pull_request_id = pr.pull_request_id) THEN 1 END) as prs_without_reviewers
+		FROM pull_requests pr
+	`).Scan(
+		&stats.TotalPRs,
+		&stats.OpenPRs,
+		&stats.MergedPRs,
+		&stats.ClosedPRs,
+		&stats.DraftPRs,
+		&stats.PRsWithReviewers,
+		&stats.PRsWithoutReviewers,
+	)
+	return stats, err
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+type teamRepository struct {
+	t *tx
+}
+
+func (r teamRepository) Create(teamName string) error {
+	return r.t.exec(false, "INSERT INTO teams (team_name) VALUES ($1)", teamName)
+}
+
+func (r teamRepository) Get(teamName string) (*models.TeamSettings, error) {
+	settings := models.TeamSettings{TeamName: teamName}
+	err := r.t.tx.QueryRowContext(r.t.ctx, `
+		SELECT reviewer_strategy, reviewers_count, min_approvals, block_on_changes_requested, default_max_open_reviews
+		FROM teams
+		WHERE team_name = $1
+	`, teamName).Scan(&settings.ReviewerStrategy, &settings.ReviewersCount, &settings.MinApprovals, &settings.BlockOnChangesRequested, &settings.DefaultMaxOpenReviews)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &settings, nil
+}
+
+func (r teamRepository) UpdateSettings(settings models.TeamSettings) error {
+	return r.t.exec(true, `
+		UPDATE teams
+		SET reviewer_strategy = $1, reviewers_count = $2, min_approvals = $3, block_on_changes_requested = $4, default_max_open_reviews = $5
+		WHERE team_name = $6
+	`, settings.ReviewerStrategy, settings.ReviewersCount, settings.MinApprovals, settings.BlockOnChangesRequested, settings.DefaultMaxOpenReviews, settings.TeamName)
+}
+
+func (r teamRepository) RoundRobinCursor(teamName string) (string, error) {
+	var cursor sql.NullString
+	err := r.t.tx.QueryRowContext(r.t.ctx, "SELECT round_robin_cursor FROM teams WHERE team_name = $1", teamName).Scan(&cursor)
+	if err != nil {
+		return "", notFound(err)
+	}
+	return cursor.String, nil
+}
+
+func (r teamRepository) SetRoundRobinCursor(teamName, userID string) error {
+	return r.t.exec(true, `
+		UPDATE teams
+		SET round_robin_cursor = $1
+		WHERE team_name = $2
+	`, sql.NullString{String: userID, Valid: userID != ""}, teamName)
+}
+
+// Rename relies on ON UPDATE CASCADE to rename members and fallback teams
+func (r teamRepository) Rename(teamName, newName string) error {
+	if err := r.t.exec(true, "UPDATE teams SET team_name = $2 WHERE team_name = $1", teamName, newName); err != nil {
+		return err
+	}
+	return r.t.exec(false, "UPDATE pr_reviewers SET fallback_team = $2 WHERE fallback_team = $1", teamName, newName)
+}
+
+// Delete relies on ON DELETE CASCADE to drop the team from fallback teams;
+// members block the deletion
+func (r teamRepository) Delete(teamName string) error {
+	return r.t.exec(true, "DELETE FROM teams WHERE team_name = $1", teamName)
+}
+
+func (r teamRepository) FallbackTeams(teamName string) ([]string, error) {
+	if _, err := r.Get(teamName); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT fallback_team
+		FROM team_fallbacks
+		WHERE team_name = $1
+		ORDER BY position
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fallbackTeams []string
+	for rows.Next() {
+		var fallbackTeam string
+		if err := rows.Scan(&fallbackTeam); err != nil {
+			return nil, err
+		}
+		fallbackTeams = append(fallbackTeams, fallbackTeam)
+	}
+	return fallbackTeams, rows.Err()
+}
+
+func (r teamRepository) SetFallbackTeams(teamName string, fallbackTeams []string) error {
+	if _, err := r.Get(teamName); err != nil {
+		return err
+	}
+	if err := r.t.exec(false, "DELETE FROM team_fallbacks WHERE team_name = $1", teamName); err != nil {
+		return err
+	}
+	for i, fallbackTeam := range fallbackTeams {
+		err := r.t.exec(false, `
+			INSERT INTO team_fallbacks (team_name, fallback_team, position)
+			VALUES ($1, $2, $3)
+		`, teamName, fallbackTeam, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r teamRepository) List(filter models.TeamFilter) ([]models.TeamSummary, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.NamePrefix != "" {
+		where("lower(t.team_name) LIKE lower($%d)", likePrefix(filter.NamePrefix))
+	}
+	if filter.After != "" {
+		where(`t.team_name COLLATE "C" > $%d`, filter.After)
+	}
+
+	query := `
+		SELECT t.team_name, COUNT(u.user_id), COUNT(u.user_id) FILTER (WHERE u.is_active)
+		FROM teams t
+		LEFT JOIN users u ON u.team_name = t.team_name`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	// Byte order keeps pagination independent of the database locale
+	query += fmt.Sprintf(` GROUP BY t.team_name ORDER BY t.team_name COLLATE "C" LIMIT $%d`, len(args))
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []models.TeamSummary
+	for rows.Next() {
+		var team models.TeamSummary
+		if err := rows.Scan(&team.TeamName, &team.MemberCount, &team.ActiveMembers); err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
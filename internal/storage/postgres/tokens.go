@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+	"github.com/lib/pq"
+)
+
+type tokenRepository struct {
+	t *tx
+}
+
+func (r tokenRepository) Create(token *models.APIToken) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	scopes := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO api_tokens (name, token_hash, scopes, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, token.Name, token.Hash, pq.Array(scopes), nullString(token.UserID), token.CreatedAt).Scan(&token.ID)
+}
+
+func (r tokenRepository) GetByHash(hash string) (*models.APIToken, error) {
+	tokens, err := r.query("WHERE token_hash = $1", hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return &tokens[0], nil
+}
+
+func (r tokenRepository) List() ([]models.APIToken, error) {
+	return r.query("")
+}
+
+func (r tokenRepository) Delete(id int64) error {
+	return r.t.exec(true, "DELETE FROM api_tokens WHERE id = $1", id)
+}
+
+func (r tokenRepository) query(where string, args ...interface{}) ([]models.APIToken, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT id, name, token_hash, scopes, user_id, created_at
+		FROM api_tokens
+		`+where+`
+		ORDER BY id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		var token models.APIToken
+		var scopes []string
+		var userID sql.NullString
+		if err := rows.Scan(&token.ID, &token.Name, &token.Hash, pq.Array(&scopes), &userID, &token.CreatedAt); err != nil {
+			return nil, err
+		}
+		token.UserID = userID.String
+		for _, scope := range scopes {
+			token.Scopes = append(token.Scopes, models.Scope(scope))
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+type userRepository struct {
+	t *tx
+}
+
+func (r userRepository) Upsert(user models.User) error {
+	return r.t.exec(false, `
+		INSERT INTO users (user_id, username, team_name, is_active, max_open_reviews, role)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id)
+		DO UPDATE SET username = EXCLUDED.username, team_name = EXCLUDED.team_name, is_active = EXCLUDED.is_active, max_open_reviews = EXCLUDED.max_open_reviews, role = EXCLUDED.role, updated_at = CURRENT_TIMESTAMP
+	`, user.UserID, user.Username, nullString(user.TeamName), user.IsActive, user.MaxOpenReviews, user.Role)
+}
+
+func (r userRepository) Get(userID string) (*models.User, error) {
+	var user models.User
+	err := r.t.tx.QueryRowContext(r.t.ctx, `
+		SELECT user_id, username, COALESCE(team_name, ''), is_active, max_open_reviews, role
+		FROM users
+		WHERE user_id = $1
+	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.MaxOpenReviews, &user.Role)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r userRepository) ListByTeam(teamName string) ([]models.User, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT user_id, username, COALESCE(team_name, ''), is_active, max_open_reviews, role
+		FROM users
+		WHERE team_name = $1
+		ORDER BY user_id
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.MaxOpenReviews, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r userRepository) List(filter models.UserFilter) ([]models.User, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.TeamName != "" {
+		where("team_name = $%d", filter.TeamName)
+	}
+	if filter.IsActive != nil {
+		where("is_active = $%d", *filter.IsActive)
+	}
+	if filter.NamePrefix != "" {
+		where("lower(username) LIKE lower($%d)", likePrefix(filter.NamePrefix))
+	}
+	if filter.After != "" {
+		where(`user_id COLLATE "C" > $%d`, filter.After)
+	}
+
+	query := "SELECT user_id, username, COALESCE(team_name, ''), is_active, max_open_reviews, role FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	// Byte order keeps pagination independent of the database locale
+	query += fmt.Sprintf(` ORDER BY user_id COLLATE "C" LIMIT $%d`, len(args))
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.MaxOpenReviews, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r userRepository) SetActive(userID string, isActive bool) error {
+	return r.t.exec(true, `
+		UPDATE users
+		SET is_active = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`, isActive, userID)
+}
+
+func (r userRepository) SetMaxOpenReviews(userID string, limit *int) error {
+	return r.t.exec(true, `
+		UPDATE users
+		SET max_open_reviews = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`, limit, userID)
+}
+
+func (r userRepository) SetRole(userID string, role models.Role) error {
+	return r.t.exec(true, `
+		UPDATE users
+		SET role = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`, role, userID)
+}
+
+func (r userRepository) SetTeam(userID, teamName string) error {
+	return r.t.exec(true, `
+		UPDATE users
+		SET team_name = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2
+	`, nullString(teamName), userID)
+}
+
+func (r userRepository) ActiveLoads(teamName string, excludeIDs []string, at time.Time) ([]storage.ReviewerLoad, error) {
+	if excludeIDs == nil {
+		excludeIDs = []string{}
+	}
+
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT u.user_id, COUNT(pr.pull_request_id), MAX(prr.assigned_at), COALESCE(u.max_open_reviews, t.default_max_open_reviews)
+		FROM users u
+		INNER JOIN teams t ON t.team_name = u.team_name
+		LEFT JOIN pr_reviewers prr ON prr.reviewer_id = u.user_id
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = prr.pull_request_id AND pr.status = 'OPEN'
+		WHERE u.team_name = $1 AND u.is_active = true AND NOT (u.user_id = ANY($2))
+			AND NOT EXISTS (
+				SELECT 1 FROM user_availability a
+				WHERE a.user_id = u.user_id AND a.starts_at <= $3 AND a.ends_at > $3
+			)
+		GROUP BY u.user_id, t.default_max_open_reviews
+		ORDER BY u.user_id
+	`, teamName, pq.Array(excludeIDs), at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loads []storage.ReviewerLoad
+	for rows.Next() {
+		var load storage.ReviewerLoad
+		if err := rows.Scan(&load.UserID, &load.OpenReviews, &load.LastAssignedAt, &load.MaxOpenReviews); err != nil {
+			return nil, err
+		}
+		loads = append(loads, load)
+	}
+	return loads, rows.Err()
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+	"github.com/lib/pq"
+)
+
+type webhookRepository struct {
+	t *tx
+}
+
+func (r webhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	events := make([]string, 0, len(sub.Events))
+	for _, e := range sub.Events {
+		events = append(events, string(e))
+	}
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		INSERT INTO webhook_subscriptions (url, secret, events, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, sub.URL, sub.Secret, pq.Array(events), sub.CreatedAt).Scan(&sub.ID)
+}
+
+func (r webhookRepository) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, `
+		SELECT id, url, secret, events, created_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.WebhookSubscription
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		var events []string
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&events), &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			sub.Events = append(sub.Events, models.EventType(e))
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r webhookRepository) DeleteSubscription(id int64) error {
+	return r.t.exec(true, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+}
+
+func (r webhookRepository) EnqueueDelivery(delivery *models.WebhookDelivery) error {
+	if r.t.readOnly {
+		return storage.ErrReadOnly
+	}
+	// The second SELECT does not see the row being inserted, so it returns
+	// the existing delivery only if the insert was skipped
+	return r.t.tx.QueryRowContext(r.t.ctx, `
+		WITH inserted AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (subscription_id, event_id) DO NOTHING
+			RETURNING id
+		)
+		SELECT id FROM inserted
+		UNION ALL
+		SELECT id FROM webhook_deliveries WHERE subscription_id = $1 AND event_id = $2
+		LIMIT 1
+	`, delivery.SubscriptionID, delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.LastError, delivery.CreatedAt, delivery.DeliveredAt).Scan(&delivery.ID)
+}
+
+func (r webhookRepository) DueDeliveries(at time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = 'PENDING' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at, id
+		LIMIT $2
+	`, at, limit)
+}
+
+func (r webhookRepository) ListDeliveries(subscriptionID int64) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(`
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY id DESC
+	`, subscriptionID)
+}
+
+func (r webhookRepository) UpdateDelivery(delivery models.WebhookDelivery) error {
+	return r.t.exec(true, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, delivered_at = $5
+		WHERE id = $6
+	`, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+}
+
+func (r webhookRepository) queryDeliveries(query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := r.t.tx.QueryContext(r.t.ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
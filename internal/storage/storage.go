@@ -0,0 +1,278 @@
+// Package storage defines the persistence layer used by the service.
+//
+// All reads and writes happen inside a transaction obtained from a Store.
+// Implementations live in the postgres and memory subpackages and are checked
+// by the shared contract suite in storagetest.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("storage: not found")
+	// ErrReadOnly is returned when a write is attempted inside a View transaction
+	ErrReadOnly = errors.New("storage: read-only transaction")
+)
+
+// Store runs functions inside transactions. The transaction is bound to ctx:
+// once ctx is done, pending operations fail and nothing is committed.
+type Store interface {
+	// Update runs fn in a read-write transaction. The transaction is committed
+	// if fn returns nil and rolled back otherwise.
+	Update(ctx context.Context, fn func(tx Tx) error) error
+	// View runs fn in a read-only transaction
+	View(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// Tx gives access to the repositories within a single transaction
+type Tx interface {
+	Teams() TeamRepository
+	Users() UserRepository
+	PullRequests() PullRequestRepository
+	Reviewers() ReviewerRepository
+	Availability() AvailabilityRepository
+	CodeOwners() CodeOwnerRepository
+	Webhooks() WebhookRepository
+	Outbox() OutboxRepository
+	Identities() IdentityRepository
+	Tokens() TokenRepository
+	Audit() AuditRepository
+	Assignments() AssignmentRepository
+	Stats() StatsRepository
+}
+
+// DefaultTeamSettings returns the settings a newly created team starts with
+func DefaultTeamSettings(teamName string) models.TeamSettings {
+	return models.TeamSettings{
+		TeamName:                teamName,
+		ReviewerStrategy:        models.StrategyLeastLoaded,
+		ReviewersCount:          2,
+		MinApprovals:            0,
+		BlockOnChangesRequested: true,
+	}
+}
+
+// TeamRepository stores teams and their settings
+type TeamRepository interface {
+	// Create inserts a team with DefaultTeamSettings
+	Create(teamName string) error
+	// Get returns the settings of a team or ErrNotFound
+	Get(teamName string) (*models.TeamSettings, error)
+	// UpdateSettings overwrites the settings of an existing team
+	UpdateSettings(settings models.TeamSettings) error
+	// RoundRobinCursor returns the reviewer most recently picked by
+	// round-robin in the team, or "" if there is none
+	RoundRobinCursor(teamName string) (string, error)
+	// SetRoundRobinCursor stores the round-robin position of the team;
+	// "" resets it
+	SetRoundRobinCursor(teamName, userID string) error
+	// FallbackTeams returns the fallback teams of a team in priority order
+	// or ErrNotFound
+	FallbackTeams(teamName string) ([]string, error)
+	// SetFallbackTeams replaces the fallback teams of a team or returns
+	// ErrNotFound
+	SetFallbackTeams(teamName string, fallbackTeams []string) error
+	// List returns at most filter.Limit teams matching the filter ordered
+	// by name
+	List(filter models.TeamFilter) ([]models.TeamSummary, error)
+	// Rename renames a team together with its members, its place in the
+	// fallback teams of other teams and reviewers borrowed from it. The
+	// assignment history keeps the old name. Returns ErrNotFound for
+	// unknown teams.
+	Rename(teamName, newName string) error
+	// Delete deletes a team without members, also removing it from the
+	// fallback teams of other teams. Returns ErrNotFound for unknown teams.
+	Delete(teamName string) error
+}
+
+// ReviewerLoad is an active user together with their review workload
+type ReviewerLoad struct {
+	UserID string
+	// OpenReviews is the number of OPEN pull requests the user reviews
+	OpenReviews int
+	// LastAssignedAt is the time the user was last assigned to any PR
+	LastAssignedAt *time.Time
+	// MaxOpenReviews is the user's effective open review limit: their own
+	// limit or the team default; 0 means unlimited
+	MaxOpenReviews int
+}
+
+// UserRepository stores users
+type UserRepository interface {
+	// Upsert creates a user or overwrites an existing one
+	Upsert(user models.User) error
+	// Get returns a user or ErrNotFound
+	Get(userID string) (*models.User, error)
+	// ListByTeam returns members of a team ordered by user_id
+	ListByTeam(teamName string) ([]models.User, error)
+	// SetActive changes the active flag of a user or returns ErrNotFound
+	SetActive(userID string, isActive bool) error
+	// SetMaxOpenReviews changes the open review limit of a user; nil resets
+	// it to the team default. Returns ErrNotFound for unknown users.
+	SetMaxOpenReviews(userID string, limit *int) error
+	// SetRole changes the role of a user or returns ErrNotFound
+	SetRole(userID string, role models.Role) error
+	// SetTeam moves a user to another team; "" removes the user from their
+	// team. Returns ErrNotFound for unknown users.
+	SetTeam(userID, teamName string) error
+	// List returns at most filter.Limit users matching the filter ordered
+	// by user_id
+	List(filter models.UserFilter) ([]models.User, error)
+	// ActiveLoads returns active members of a team, except excludeIDs and
+	// users out of office at the given moment, with their review workload
+	// ordered by user_id
+	ActiveLoads(teamName string, excludeIDs []string, at time.Time) ([]ReviewerLoad, error)
+}
+
+// PullRequestRepository stores pull requests without their reviewers
+type PullRequestRepository interface {
+	// Create inserts a pull request together with its changed files
+	Create(pr models.PullRequest) error
+	// Get returns a pull request without reviewers or ErrNotFound.
+	// Inside Update transactions the row is locked until the end of the
+	// transaction.
+	Get(prID string) (*models.PullRequest, error)
+	// Update overwrites the mutable fields of a pull request: status,
+	// assignment strategy, force merge and understaffed flags and timestamps
+	Update(pr models.PullRequest) error
+	// ListByReviewer returns pull requests the user is assigned to review,
+	// newest first
+	ListByReviewer(userID string) ([]models.PullRequestShort, error)
+	// List returns at most filter.Limit pull requests matching the filter in
+	// its sort order
+	List(filter models.PullRequestFilter) ([]models.PullRequestShort, error)
+}
+
+// ReviewerRepository stores reviewer assignments of pull requests
+type ReviewerRepository interface {
+	// List returns reviewers of a pull request ordered by user_id
+	List(prID string) ([]models.Reviewer, error)
+	// Add assigns a reviewer to a pull request
+	Add(prID string, reviewer models.Reviewer) error
+	// Replace swaps an assigned reviewer for another one or returns ErrNotFound
+	Replace(prID, oldUserID string, reviewer models.Reviewer) error
+	// SetVerdict records a reviewer's verdict or returns ErrNotFound if the
+	// user is not assigned to the pull request
+	SetVerdict(prID, userID string, state models.ReviewState, reviewedAt time.Time) error
+}
+
+// AvailabilityRepository stores out-of-office windows of users
+type AvailabilityRepository interface {
+	// Create inserts a window and fills in its ID
+	Create(window *models.AvailabilityWindow) error
+	// ListByUser returns windows of a user ordered by start
+	ListByUser(userID string) ([]models.AvailabilityWindow, error)
+	// Delete removes a window of a user or returns ErrNotFound
+	Delete(userID string, id int64) error
+	// PendingReassignments returns windows covering the given moment whose
+	// open reviews should be, but have not yet been, reassigned
+	PendingReassignments(at time.Time) ([]models.AvailabilityWindow, error)
+	// MarkReassigned records that the open reviews of a window were reassigned.
+	// It returns ErrNotFound if the window does not exist or is already
+	// marked, so that concurrent callers process a window once.
+	MarkReassigned(id int64, at time.Time) error
+}
+
+// CodeOwnerRepository stores the code ownership rules
+type CodeOwnerRepository interface {
+	// List returns the rules in CODEOWNERS file order
+	List() ([]models.CodeOwnerRule, error)
+	// Replace swaps all rules for the given ones
+	Replace(rules []models.CodeOwnerRule) error
+}
+
+// WebhookRepository stores webhook subscriptions and the deliveries queued
+// for them
+type WebhookRepository interface {
+	// CreateSubscription inserts a subscription and fills in its ID
+	CreateSubscription(sub *models.WebhookSubscription) error
+	// ListSubscriptions returns all subscriptions ordered by id
+	ListSubscriptions() ([]models.WebhookSubscription, error)
+	// DeleteSubscription removes a subscription together with its
+	// deliveries or returns ErrNotFound
+	DeleteSubscription(id int64) error
+	// EnqueueDelivery inserts a delivery and fills in its ID. If the
+	// subscription already has a delivery of the same event, nothing is
+	// inserted and the ID of the existing delivery is filled in.
+	EnqueueDelivery(delivery *models.WebhookDelivery) error
+	// DueDeliveries returns up to limit PENDING deliveries whose next attempt
+	// is due at the given moment, oldest attempt first
+	DueDeliveries(at time.Time, limit int) ([]models.WebhookDelivery, error)
+	// ListDeliveries returns the deliveries of a subscription, newest first
+	ListDeliveries(subscriptionID int64) ([]models.WebhookDelivery, error)
+	// UpdateDelivery records the outcome of a delivery attempt: status,
+	// attempts, next attempt, last error and delivery time
+	UpdateDelivery(delivery models.WebhookDelivery) error
+}
+
+// IdentityRepository maps logins on external providers to users
+type IdentityRepository interface {
+	// Get returns the identity of a provider login or ErrNotFound
+	Get(provider models.IdentityProvider, login string) (*models.UserIdentity, error)
+	// List returns all identities ordered by provider and login
+	List() ([]models.UserIdentity, error)
+	// Upsert maps a provider login to a user, replacing an existing mapping
+	Upsert(identity models.UserIdentity) error
+	// Delete removes the identity of a provider login or returns ErrNotFound
+	Delete(provider models.IdentityProvider, login string) error
+}
+
+// OutboxRepository stores events written together with the changes that
+// caused them until they are published
+type OutboxRepository interface {
+	// Append inserts an event and fills in its ID
+	Append(event *models.OutboxEvent) error
+	// Pending returns up to limit unpublished events in the order they were
+	// appended
+	Pending(limit int) ([]models.OutboxEvent, error)
+	// MarkPublished records that an event was published or returns ErrNotFound
+	MarkPublished(id int64, at time.Time) error
+	// RecordFailure counts a failed attempt to publish an event and keeps its
+	// error, or returns ErrNotFound
+	RecordFailure(id int64, lastError string) error
+}
+
+// AuditRepository stores the append-only audit log
+type AuditRepository interface {
+	// Append inserts an entry and fills in its ID
+	Append(entry *models.AuditEntry) error
+	// List returns up to filter.Limit entries matching the filter, newest first
+	List(filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+// TokenRepository stores API tokens by the hash of the token
+type TokenRepository interface {
+	// Create inserts a token and fills in its ID
+	Create(token *models.APIToken) error
+	// GetByHash returns the token with the given hash or ErrNotFound
+	GetByHash(hash string) (*models.APIToken, error)
+	// List returns all tokens ordered by id
+	List() ([]models.APIToken, error)
+	// Delete removes a token or returns ErrNotFound
+	Delete(id int64) error
+}
+
+// AssignmentRepository stores the append-only assignment history of
+// pull requests
+type AssignmentRepository interface {
+	// Append inserts an event and fills in its ID
+	Append(event *models.AssignmentEvent) error
+	// ListByPullRequest returns the history of a pull request, oldest first
+	ListByPullRequest(prID string) ([]models.AssignmentEvent, error)
+}
+
+// StatsRepository computes aggregate statistics
+type StatsRepository interface {
+	// UserAssignments returns assignment counters of every user, most
+	// assigned first. Assignments are counted from the assignment history,
+	// so reviews reassigned away still count for the original reviewer.
+	UserAssignments() ([]models.UserAssignmentStats, error)
+	// PullRequests returns counters of pull requests by status and reviewers
+	PullRequests() (models.PRStatistics, error)
+}
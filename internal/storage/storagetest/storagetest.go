@@ -0,0 +1,1481 @@
+// Package storagetest contains the contract test suite every storage.Store
+// implementation must pass
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avito-tech/pr-reviewer-service/internal/models"
+	"github.com/avito-tech/pr-reviewer-service/internal/storage"
+)
+
+// Run runs the contract suite. newStore must return an empty store.
+func Run(t *testing.T, newStore func(t *testing.T) storage.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store storage.Store)
+	}{
+		{"Transactions", testTransactions},
+		{"Teams", testTeams},
+		{"FallbackTeams", testFallbackTeams},
+		{"RenameTeam", testRenameTeam},
+		{"DeleteTeam", testDeleteTeam},
+		{"Users", testUsers},
+		{"ListTeams", testListTeams},
+		{"ListUsers", testListUsers},
+		{"PullRequests", testPullRequests},
+		{"ListPullRequests", testListPullRequests},
+		{"Reviewers", testReviewers},
+		{"ActiveLoads", testActiveLoads},
+		{"Availability", testAvailability},
+		{"CodeOwners", testCodeOwners},
+		{"Webhooks", testWebhooks},
+		{"Outbox", testOutbox},
+		{"Identities", testIdentities},
+		{"Tokens", testTokens},
+		{"Audit", testAudit},
+		{"Assignments", testAssignments},
+		{"Stats", testStats},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// at returns a fixed UTC time with microsecond precision, which every
+// backend can store without loss
+func at(minute int) *time.Time {
+	ts := time.Date(2024, 1, 1, 12, minute, 0, 0, time.UTC)
+	return &ts
+}
+
+func update(t *testing.T, store storage.Store, fn func(tx storage.Tx) error) {
+	t.Helper()
+	if err := store.Update(context.Background(), fn); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+}
+
+func view(t *testing.T, store storage.Store, fn func(tx storage.Tx) error) {
+	t.Helper()
+	if err := store.View(context.Background(), fn); err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+}
+
+// seed creates a team with the given active members
+func seed(t *testing.T, store storage.Store, teamName string, userIDs ...string) {
+	t.Helper()
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().Create(teamName); err != nil {
+			return err
+		}
+		for _, id := range userIDs {
+			if err := tx.Users().Upsert(models.User{UserID: id, Username: "name-" + id, TeamName: teamName, IsActive: true, Role: models.RoleMember}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func createPR(t *testing.T, store storage.Store, prID, authorID string, status models.PullRequestStatus, createdAt *time.Time, reviewerIDs ...string) {
+	t.Helper()
+	update(t, store, func(tx storage.Tx) error {
+		err := tx.PullRequests().Create(models.PullRequest{
+			PullRequestID:   prID,
+			PullRequestName: "PR " + prID,
+			AuthorID:        authorID,
+			Status:          status,
+			CreatedAt:       createdAt,
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range reviewerIDs {
+			if err := tx.Reviewers().Add(prID, models.Reviewer{UserID: id, State: models.ReviewPending, AssignedAt: createdAt}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func testTransactions(t *testing.T, store storage.Store) {
+	errRollback := errors.New("rollback")
+	err := store.Update(context.Background(), func(tx storage.Tx) error {
+		if err := tx.Teams().Create("backend"); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expected fn error to be returned, got %v", err)
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get("backend"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected rolled back team to be absent, got %v", err)
+		}
+		return nil
+	})
+
+	err = store.View(context.Background(), func(tx storage.Tx) error {
+		return tx.Teams().Create("backend")
+	})
+	if !errors.Is(err, storage.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly for write in View, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = store.Update(ctx, func(tx storage.Tx) error {
+		return tx.Teams().Create("backend")
+	})
+	if err == nil {
+		t.Fatal("Expected error for cancelled context")
+	}
+	view(t, store, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get("backend"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected team from cancelled transaction to be absent, got %v", err)
+		}
+		return nil
+	})
+}
+
+func testTeams(t *testing.T, store storage.Store) {
+	seed(t, store, "backend")
+
+	view(t, store, func(tx storage.Tx) error {
+		settings, err := tx.Teams().Get("backend")
+		if err != nil {
+			return err
+		}
+		if *settings != storage.DefaultTeamSettings("backend") {
+			t.Errorf("Expected default settings, got %+v", settings)
+		}
+		if _, err := tx.Teams().Get("missing"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		cursor, err := tx.Teams().RoundRobinCursor("backend")
+		if err != nil {
+			return err
+		}
+		if cursor != "" {
+			t.Errorf("Expected empty cursor, got %q", cursor)
+		}
+		return nil
+	})
+
+	updated := models.TeamSettings{
+		TeamName:                "backend",
+		ReviewerStrategy:        models.StrategyRoundRobin,
+		ReviewersCount:          3,
+		MinApprovals:            1,
+		BlockOnChangesRequested: false,
+		DefaultMaxOpenReviews:   5,
+	}
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().UpdateSettings(updated); err != nil {
+			return err
+		}
+		if err := tx.Teams().SetRoundRobinCursor("backend", "u2"); err != nil {
+			return err
+		}
+		missing := updated
+		missing.TeamName = "missing"
+		if err := tx.Teams().UpdateSettings(missing); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound updating missing team, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		settings, err := tx.Teams().Get("backend")
+		if err != nil {
+			return err
+		}
+		if *settings != updated {
+			t.Errorf("Expected %+v, got %+v", updated, settings)
+		}
+		cursor, err := tx.Teams().RoundRobinCursor("backend")
+		if err != nil {
+			return err
+		}
+		if cursor != "u2" {
+			t.Errorf("Expected cursor u2, got %q", cursor)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		return tx.Teams().SetRoundRobinCursor("backend", "")
+	})
+	view(t, store, func(tx storage.Tx) error {
+		cursor, err := tx.Teams().RoundRobinCursor("backend")
+		if err != nil {
+			return err
+		}
+		if cursor != "" {
+			t.Errorf("Expected reset cursor, got %q", cursor)
+		}
+		return nil
+	})
+}
+
+func testRenameTeam(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2")
+	seed(t, store, "platform", "p1")
+	createPR(t, store, "pr-1", "u1", models.StatusOpen, at(0))
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().SetFallbackTeams("platform", []string{"backend"}); err != nil {
+			return err
+		}
+		if err := tx.Reviewers().Add("pr-1", models.Reviewer{UserID: "p1", State: models.ReviewPending, FallbackTeam: "platform"}); err != nil {
+			return err
+		}
+		if err := tx.Teams().Rename("platform", "infra"); err != nil {
+			return err
+		}
+		if err := tx.Teams().Rename("backend", "core"); err != nil {
+			return err
+		}
+		if err := tx.Teams().Rename("missing", "other"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get("platform"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected the old name to be gone, got %v", err)
+		}
+		settings, err := tx.Teams().Get("infra")
+		if err != nil {
+			return err
+		}
+		if settings.TeamName != "infra" {
+			t.Errorf("Expected settings of infra, got %+v", settings)
+		}
+		fallbacks, err := tx.Teams().FallbackTeams("infra")
+		if err != nil {
+			return err
+		}
+		if len(fallbacks) != 1 || fallbacks[0] != "core" {
+			t.Errorf("Expected fallback core, got %v", fallbacks)
+		}
+		members, err := tx.Users().ListByTeam("core")
+		if err != nil {
+			return err
+		}
+		if len(members) != 2 {
+			t.Errorf("Expected members to follow the rename, got %+v", members)
+		}
+		reviewers, err := tx.Reviewers().List("pr-1")
+		if err != nil {
+			return err
+		}
+		if len(reviewers) != 1 || reviewers[0].FallbackTeam != "infra" {
+			t.Errorf("Expected the borrowed reviewer to follow the rename, got %+v", reviewers)
+		}
+		return nil
+	})
+}
+
+func testDeleteTeam(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1")
+	seed(t, store, "platform")
+	seed(t, store, "mobile")
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().SetFallbackTeams("mobile", []string{"platform", "backend"}); err != nil {
+			return err
+		}
+		if err := tx.Teams().SetFallbackTeams("platform", []string{"backend"}); err != nil {
+			return err
+		}
+		return tx.Teams().Delete("platform")
+	})
+
+	// Members must be moved out first
+	err := store.Update(context.Background(), func(tx storage.Tx) error {
+		return tx.Teams().Delete("backend")
+	})
+	if err == nil {
+		t.Errorf("Expected a team with members not to be deleted")
+	}
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().Delete("missing"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		if _, err := tx.Teams().Get("platform"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected platform to be deleted, got %v", err)
+		}
+		if _, err := tx.Users().Get("u1"); err != nil {
+			t.Errorf("Expected u1 to remain, got %v", err)
+		}
+		fallbacks, err := tx.Teams().FallbackTeams("mobile")
+		if err != nil {
+			return err
+		}
+		if len(fallbacks) != 1 || fallbacks[0] != "backend" {
+			t.Errorf("Expected fallback backend only, got %v", fallbacks)
+		}
+		return nil
+	})
+}
+
+func testUsers(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u2", "u1")
+	seed(t, store, "frontend", "u3", "u4")
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Users().Upsert(models.User{UserID: "u3", Username: "Carol", TeamName: "backend", IsActive: true}); err != nil {
+			return err
+		}
+		if err := tx.Users().SetActive("u1", false); err != nil {
+			return err
+		}
+		if err := tx.Users().SetActive("missing", false); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		limit := 4
+		if err := tx.Users().SetMaxOpenReviews("u2", &limit); err != nil {
+			return err
+		}
+		if err := tx.Users().SetMaxOpenReviews("missing", &limit); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := tx.Users().SetRole("u2", models.RoleTeamLead); err != nil {
+			return err
+		}
+		if err := tx.Users().SetRole("missing", models.RoleAdmin); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := tx.Users().SetTeam("u4", ""); err != nil {
+			return err
+		}
+		if err := tx.Users().SetTeam("missing", "backend"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		user, err := tx.Users().Get("u1")
+		if err != nil {
+			return err
+		}
+		if user.IsActive || user.TeamName != "backend" || user.Username != "name-u1" {
+			t.Errorf("Unexpected user %+v", user)
+		}
+		if _, err := tx.Users().Get("missing"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if user.MaxOpenReviews != nil {
+			t.Errorf("Expected no own limit for u1, got %d", *user.MaxOpenReviews)
+		}
+		limited, err := tx.Users().Get("u2")
+		if err != nil {
+			return err
+		}
+		if limited.MaxOpenReviews == nil || *limited.MaxOpenReviews != 4 {
+			t.Errorf("Expected limit 4 for u2, got %v", limited.MaxOpenReviews)
+		}
+		if limited.Role != models.RoleTeamLead || user.Role != models.RoleMember {
+			t.Errorf("Expected u2 to lead and u1 to be a member, got %s and %s", limited.Role, user.Role)
+		}
+
+		members, err := tx.Users().ListByTeam("backend")
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, member := range members {
+			ids = append(ids, member.UserID)
+		}
+		if len(ids) != 3 || ids[0] != "u1" || ids[1] != "u2" || ids[2] != "u3" {
+			t.Errorf("Expected members u1, u2, u3 ordered by id, got %v", ids)
+		}
+
+		frontend, err := tx.Users().ListByTeam("frontend")
+		if err != nil {
+			return err
+		}
+		if len(frontend) != 0 {
+			t.Errorf("Expected moved and removed users to leave frontend, got %+v", frontend)
+		}
+
+		removed, err := tx.Users().Get("u4")
+		if err != nil {
+			return err
+		}
+		if removed.TeamName != "" {
+			t.Errorf("Expected u4 to have no team, got %s", removed.TeamName)
+		}
+		all, err := tx.Users().List(models.UserFilter{Limit: 10})
+		if err != nil {
+			return err
+		}
+		if len(all) != 4 || all[3].UserID != "u4" || all[3].TeamName != "" {
+			t.Errorf("Expected u4 without a team listed last, got %+v", all)
+		}
+		return nil
+	})
+}
+
+func testListTeams(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2")
+	seed(t, store, "Billing", "u3")
+	seed(t, store, "frontend")
+	seed(t, store, "b_x")
+	update(t, store, func(tx storage.Tx) error {
+		return tx.Users().SetActive("u2", false)
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		teams, err := tx.Teams().List(models.TeamFilter{Limit: 10})
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(teams) != "[{Billing 1 1} {b_x 0 0} {backend 2 1} {frontend 0 0}]" {
+			t.Errorf("Expected all teams ordered by name with member counts, got %v", teams)
+		}
+
+		teams, err = tx.Teams().List(models.TeamFilter{NamePrefix: "B", After: "Billing", Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(teams) != 1 || teams[0].TeamName != "b_x" {
+			t.Errorf("Expected b_x after Billing, got %v", teams)
+		}
+
+		// LIKE wildcards in the prefix match literally
+		teams, err = tx.Teams().List(models.TeamFilter{NamePrefix: "b_", Limit: 10})
+		if err != nil {
+			return err
+		}
+		if len(teams) != 1 || teams[0].TeamName != "b_x" {
+			t.Errorf("Expected only b_x for prefix b_, got %v", teams)
+		}
+		return nil
+	})
+}
+
+func testListUsers(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3")
+	seed(t, store, "frontend", "u4")
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Users().Upsert(models.User{UserID: "u2", Username: "Bob", TeamName: "backend", IsActive: true, Role: models.RoleMember}); err != nil {
+			return err
+		}
+		return tx.Users().SetActive("u3", false)
+	})
+
+	active, inactive := true, false
+	view(t, store, func(tx storage.Tx) error {
+		for _, tt := range []struct {
+			name   string
+			filter models.UserFilter
+			want   string
+		}{
+			{"all", models.UserFilter{}, "u1,u2,u3,u4"},
+			{"team", models.UserFilter{TeamName: "backend"}, "u1,u2,u3"},
+			{"active", models.UserFilter{IsActive: &active}, "u1,u2,u4"},
+			{"inactive", models.UserFilter{IsActive: &inactive}, "u3"},
+			{"name prefix", models.UserFilter{NamePrefix: "NAME-"}, "u1,u3,u4"},
+			{"after", models.UserFilter{After: "u2"}, "u3,u4"},
+			{"limit", models.UserFilter{Limit: 2}, "u1,u2"},
+		} {
+			if tt.filter.Limit == 0 {
+				tt.filter.Limit = 10
+			}
+			users, err := tx.Users().List(tt.filter)
+			if err != nil {
+				return err
+			}
+			var ids []string
+			for _, user := range users {
+				ids = append(ids, user.UserID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+			}
+		}
+		return nil
+	})
+}
+
+func testPullRequests(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2")
+	count := 3
+	update(t, store, func(tx storage.Tx) error {
+		return tx.PullRequests().Create(models.PullRequest{
+			PullRequestID:   "pr-1",
+			PullRequestName: "Add search",
+			AuthorID:        "u1",
+			Status:          models.StatusDraft,
+			ReviewersCount:  &count,
+			ChangedFiles:    []string{"search/index.go", "README.md"},
+			CreatedAt:       at(0),
+		})
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		pr, err := tx.PullRequests().Get("pr-1")
+		if err != nil {
+			return err
+		}
+		if pr.PullRequestName != "Add search" || pr.AuthorID != "u1" || pr.Status != models.StatusDraft {
+			t.Errorf("Unexpected PR %+v", pr)
+		}
+		if pr.ReviewersCount == nil || *pr.ReviewersCount != 3 {
+			t.Errorf("Expected reviewers count 3, got %v", pr.ReviewersCount)
+		}
+		if pr.CreatedAt == nil || !pr.CreatedAt.Equal(*at(0)) {
+			t.Errorf("Expected created_at %v, got %v", at(0), pr.CreatedAt)
+		}
+		if len(pr.ChangedFiles) != 2 || pr.ChangedFiles[0] != "search/index.go" || pr.ChangedFiles[1] != "README.md" {
+			t.Errorf("Expected changed files to round-trip, got %v", pr.ChangedFiles)
+		}
+		if pr.AssignmentStrategy != "" || pr.MergedAt != nil || pr.ClosedAt != nil || pr.ForceMerged {
+			t.Errorf("Expected empty optional fields, got %+v", pr)
+		}
+		if _, err := tx.PullRequests().Get("missing"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		pr, err := tx.PullRequests().Get("pr-1")
+		if err != nil {
+			return err
+		}
+		pr.Status = models.StatusMerged
+		pr.AssignmentStrategy = models.StrategyRandom
+		pr.ForceMerged = true
+		pr.Understaffed = true
+		pr.MergedAt = at(5)
+		if err := tx.PullRequests().Update(*pr); err != nil {
+			return err
+		}
+
+		missing := *pr
+		missing.PullRequestID = "missing"
+		if err := tx.PullRequests().Update(missing); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		pr, err := tx.PullRequests().Get("pr-1")
+		if err != nil {
+			return err
+		}
+		if pr.Status != models.StatusMerged || pr.AssignmentStrategy != models.StrategyRandom || !pr.ForceMerged || !pr.Understaffed {
+			t.Errorf("Unexpected updated PR %+v", pr)
+		}
+		if pr.MergedAt == nil || !pr.MergedAt.Equal(*at(5)) {
+			t.Errorf("Expected merged_at %v, got %v", at(5), pr.MergedAt)
+		}
+		return nil
+	})
+
+	createPR(t, store, "pr-2", "u1", models.StatusOpen, at(10), "u2")
+	createPR(t, store, "pr-3", "u1", models.StatusOpen, at(20), "u2")
+	createPR(t, store, "pr-4", "u2", models.StatusOpen, at(30), "u1")
+
+	view(t, store, func(tx storage.Tx) error {
+		prs, err := tx.PullRequests().ListByReviewer("u2")
+		if err != nil {
+			return err
+		}
+		if len(prs) != 2 || prs[0].PullRequestID != "pr-3" || prs[1].PullRequestID != "pr-2" {
+			t.Errorf("Expected pr-3, pr-2 newest first, got %+v", prs)
+		}
+		if prs[0].Status != models.StatusOpen || prs[0].AuthorID != "u1" {
+			t.Errorf("Unexpected short PR %+v", prs[0])
+		}
+		return nil
+	})
+}
+
+func testListPullRequests(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2")
+	seed(t, store, "frontend", "u3")
+	createPR(t, store, "pr-1", "u1", models.StatusMerged, at(0), "u2")
+	createPR(t, store, "pr-2", "u3", models.StatusOpen, at(10), "u2")
+	createPR(t, store, "pr-3", "u1", models.StatusOpen, at(10), "u2", "u3")
+	createPR(t, store, "pr-4", "u2", models.StatusClosed, at(20), "u1")
+	createPR(t, store, "pr-5", "u1", models.StatusOpen, at(30), "u3")
+
+	ids := func(prs []models.PullRequestShort) string {
+		var ids []string
+		for _, pr := range prs {
+			ids = append(ids, pr.PullRequestID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		for _, tt := range []struct {
+			name   string
+			filter models.PullRequestFilter
+			want   string
+		}{
+			{"all newest first", models.PullRequestFilter{}, "pr-5,pr-4,pr-3,pr-2,pr-1"},
+			{"oldest first", models.PullRequestFilter{Sort: models.SortOldestFirst}, "pr-1,pr-2,pr-3,pr-4,pr-5"},
+			{"reviewer", models.PullRequestFilter{ReviewerID: "u2"}, "pr-3,pr-2,pr-1"},
+			{"statuses", models.PullRequestFilter{Statuses: []models.PullRequestStatus{models.StatusMerged, models.StatusClosed}}, "pr-4,pr-1"},
+			{"author", models.PullRequestFilter{AuthorID: "u1"}, "pr-5,pr-3,pr-1"},
+			{"author team", models.PullRequestFilter{TeamName: "frontend"}, "pr-2"},
+			{"created range", models.PullRequestFilter{From: at(10), To: at(30)}, "pr-4,pr-3,pr-2"},
+			{"limit", models.PullRequestFilter{Limit: 2}, "pr-5,pr-4"},
+			{"after newest first", models.PullRequestFilter{After: &models.PullRequestKey{CreatedAt: *at(10), PullRequestID: "pr-3"}}, "pr-2,pr-1"},
+			{"after oldest first", models.PullRequestFilter{Sort: models.SortOldestFirst, After: &models.PullRequestKey{CreatedAt: *at(10), PullRequestID: "pr-2"}}, "pr-3,pr-4,pr-5"},
+		} {
+			if tt.filter.Limit == 0 {
+				tt.filter.Limit = 10
+			}
+			prs, err := tx.PullRequests().List(tt.filter)
+			if err != nil {
+				return err
+			}
+			if got := ids(prs); got != tt.want {
+				t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+			}
+		}
+
+		prs, err := tx.PullRequests().List(models.PullRequestFilter{AuthorID: "u3", Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(prs) != 1 || prs[0].CreatedAt == nil || !prs[0].CreatedAt.Equal(*at(10)) || prs[0].Status != models.StatusOpen {
+			t.Errorf("Expected pr-2 with its creation time, got %+v", prs)
+		}
+		return nil
+	})
+}
+
+func testFallbackTeams(t *testing.T, store storage.Store) {
+	seed(t, store, "backend")
+	seed(t, store, "platform")
+	seed(t, store, "frontend")
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Teams().SetFallbackTeams("backend", []string{"platform", "frontend"}); err != nil {
+			return err
+		}
+		if err := tx.Teams().SetFallbackTeams("missing", []string{"platform"}); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for missing team, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		fallbacks, err := tx.Teams().FallbackTeams("backend")
+		if err != nil {
+			return err
+		}
+		if len(fallbacks) != 2 || fallbacks[0] != "platform" || fallbacks[1] != "frontend" {
+			t.Errorf("Expected fallbacks [platform frontend], got %v", fallbacks)
+		}
+		none, err := tx.Teams().FallbackTeams("platform")
+		if err != nil {
+			return err
+		}
+		if len(none) != 0 {
+			t.Errorf("Expected no fallbacks, got %v", none)
+		}
+		if _, err := tx.Teams().FallbackTeams("missing"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := tx.Teams().SetFallbackTeams("backend", nil); !errors.Is(err, storage.ErrReadOnly) {
+			t.Errorf("Expected ErrReadOnly, got %v", err)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		return tx.Teams().SetFallbackTeams("backend", []string{"frontend"})
+	})
+	view(t, store, func(tx storage.Tx) error {
+		fallbacks, err := tx.Teams().FallbackTeams("backend")
+		if err != nil {
+			return err
+		}
+		if len(fallbacks) != 1 || fallbacks[0] != "frontend" {
+			t.Errorf("Expected fallbacks [frontend], got %v", fallbacks)
+		}
+		return nil
+	})
+}
+
+func testReviewers(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3", "u4")
+	createPR(t, store, "pr-1", "u1", models.StatusOpen, at(0), "u3", "u2")
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Reviewers().SetVerdict("pr-1", "u2", models.ReviewApproved, *at(1)); err != nil {
+			return err
+		}
+		if err := tx.Reviewers().SetVerdict("pr-1", "u4", models.ReviewApproved, *at(1)); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for unassigned reviewer, got %v", err)
+		}
+		if err := tx.Reviewers().Replace("pr-1", "u3", models.Reviewer{UserID: "u4", State: models.ReviewPending, AssignedAt: at(2), FallbackTeam: "platform"}); err != nil {
+			return err
+		}
+		if err := tx.Reviewers().Replace("pr-1", "u3", models.Reviewer{UserID: "u1", State: models.ReviewPending}); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound replacing unassigned reviewer, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		reviewers, err := tx.Reviewers().List("pr-1")
+		if err != nil {
+			return err
+		}
+		if len(reviewers) != 2 || reviewers[0].UserID != "u2" || reviewers[1].UserID != "u4" {
+			t.Fatalf("Expected reviewers u2, u4, got %+v", reviewers)
+		}
+		if reviewers[0].State != models.ReviewApproved || reviewers[0].ReviewedAt == nil || !reviewers[0].ReviewedAt.Equal(*at(1)) {
+			t.Errorf("Expected approved verdict, got %+v", reviewers[0])
+		}
+		if reviewers[1].State != models.ReviewPending || reviewers[1].ReviewedAt != nil || !reviewers[1].AssignedAt.Equal(*at(2)) {
+			t.Errorf("Expected fresh replacement, got %+v", reviewers[1])
+		}
+		if reviewers[0].FallbackTeam != "" || reviewers[1].FallbackTeam != "platform" {
+			t.Errorf("Expected only the replacement to come from platform, got %+v", reviewers)
+		}
+
+		none, err := tx.Reviewers().List("missing")
+		if err != nil {
+			return err
+		}
+		if len(none) != 0 {
+			t.Errorf("Expected no reviewers, got %+v", none)
+		}
+		return nil
+	})
+}
+
+func testActiveLoads(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3", "u4")
+	createPR(t, store, "pr-1", "u1", models.StatusOpen, at(0), "u2")
+	createPR(t, store, "pr-2", "u1", models.StatusOpen, at(10), "u2")
+	createPR(t, store, "pr-3", "u1", models.StatusMerged, at(20), "u3")
+	update(t, store, func(tx storage.Tx) error {
+		settings := storage.DefaultTeamSettings("backend")
+		settings.DefaultMaxOpenReviews = 3
+		if err := tx.Teams().UpdateSettings(settings); err != nil {
+			return err
+		}
+		limit := 1
+		if err := tx.Users().SetMaxOpenReviews("u3", &limit); err != nil {
+			return err
+		}
+		return tx.Users().SetActive("u4", false)
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		loads, err := tx.Users().ActiveLoads("backend", []string{"u1"}, *at(30))
+		if err != nil {
+			return err
+		}
+		if len(loads) != 2 || loads[0].UserID != "u2" || loads[1].UserID != "u3" {
+			t.Fatalf("Expected loads for u2, u3, got %+v", loads)
+		}
+		if loads[0].OpenReviews != 2 || loads[0].LastAssignedAt == nil || !loads[0].LastAssignedAt.Equal(*at(10)) || loads[0].MaxOpenReviews != 3 {
+			t.Errorf("Unexpected load for u2: %+v", loads[0])
+		}
+		if loads[1].OpenReviews != 0 || loads[1].LastAssignedAt == nil || !loads[1].LastAssignedAt.Equal(*at(20)) || loads[1].MaxOpenReviews != 1 {
+			t.Errorf("Unexpected load for u3: %+v", loads[1])
+		}
+
+		all, err := tx.Users().ActiveLoads("backend", nil, *at(30))
+		if err != nil {
+			return err
+		}
+		if len(all) != 3 || all[0].UserID != "u1" || all[0].LastAssignedAt != nil {
+			t.Errorf("Expected never assigned u1 first, got %+v", all)
+		}
+		return nil
+	})
+}
+
+func testAvailability(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3")
+
+	vacation := models.AvailabilityWindow{UserID: "u2", StartsAt: *at(10), EndsAt: *at(20), Reason: "vacation", ReassignOpenReviews: true}
+	sickLeave := models.AvailabilityWindow{UserID: "u2", StartsAt: *at(0), EndsAt: *at(5)}
+	other := models.AvailabilityWindow{UserID: "u3", StartsAt: *at(10), EndsAt: *at(20)}
+	update(t, store, func(tx storage.Tx) error {
+		for _, w := range []*models.AvailabilityWindow{&vacation, &sickLeave, &other} {
+			if err := tx.Availability().Create(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if vacation.ID == 0 || vacation.ID == sickLeave.ID {
+		t.Fatalf("Expected distinct ids, got %d and %d", vacation.ID, sickLeave.ID)
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		windows, err := tx.Availability().ListByUser("u2")
+		if err != nil {
+			return err
+		}
+		if len(windows) != 2 || windows[0].ID != sickLeave.ID || windows[1].ID != vacation.ID {
+			t.Fatalf("Expected u2 windows ordered by start, got %+v", windows)
+		}
+		w := windows[1]
+		if w.Reason != "vacation" || !w.ReassignOpenReviews || !w.StartsAt.Equal(*at(10)) || !w.EndsAt.Equal(*at(20)) || w.ReassignedAt != nil {
+			t.Errorf("Unexpected window %+v", w)
+		}
+
+		pending, err := tx.Availability().PendingReassignments(*at(15))
+		if err != nil {
+			return err
+		}
+		if len(pending) != 1 || pending[0].ID != vacation.ID {
+			t.Errorf("Expected vacation pending at 12:15, got %+v", pending)
+		}
+		pending, err = tx.Availability().PendingReassignments(*at(5))
+		if err != nil {
+			return err
+		}
+		if len(pending) != 0 {
+			t.Errorf("Expected nothing pending before the window starts, got %+v", pending)
+		}
+
+		loads, err := tx.Users().ActiveLoads("backend", nil, *at(15))
+		if err != nil {
+			return err
+		}
+		if len(loads) != 1 || loads[0].UserID != "u1" {
+			t.Errorf("Expected only u1 available at 12:15, got %+v", loads)
+		}
+		loads, err = tx.Users().ActiveLoads("backend", nil, *at(20))
+		if err != nil {
+			return err
+		}
+		if len(loads) != 3 {
+			t.Errorf("Expected everyone available once windows end, got %+v", loads)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Availability().MarkReassigned(vacation.ID, *at(15)); err != nil {
+			return err
+		}
+		if err := tx.Availability().MarkReassigned(vacation.ID, *at(16)); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound marking a window twice, got %v", err)
+		}
+		if err := tx.Availability().Delete("u3", sickLeave.ID); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound deleting another user's window, got %v", err)
+		}
+		return tx.Availability().Delete("u2", sickLeave.ID)
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		pending, err := tx.Availability().PendingReassignments(*at(15))
+		if err != nil {
+			return err
+		}
+		if len(pending) != 0 {
+			t.Errorf("Expected no pending reassignments after marking, got %+v", pending)
+		}
+		windows, err := tx.Availability().ListByUser("u2")
+		if err != nil {
+			return err
+		}
+		if len(windows) != 1 || windows[0].ReassignedAt == nil || !windows[0].ReassignedAt.Equal(*at(15)) {
+			t.Errorf("Expected remaining reassigned vacation, got %+v", windows)
+		}
+		return nil
+	})
+}
+
+func testCodeOwners(t *testing.T, store storage.Store) {
+	view(t, store, func(tx storage.Tx) error {
+		rules, err := tx.CodeOwners().List()
+		if err != nil {
+			return err
+		}
+		if len(rules) != 0 {
+			t.Errorf("Expected no rules, got %+v", rules)
+		}
+		if err := tx.CodeOwners().Replace(nil); !errors.Is(err, storage.ErrReadOnly) {
+			t.Errorf("Expected ErrReadOnly, got %v", err)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		return tx.CodeOwners().Replace([]models.CodeOwnerRule{
+			{Pattern: "*", Teams: []string{"backend"}},
+			{Pattern: "/docs/", Users: []string{"u1", "u2"}},
+			{Pattern: "*.sql", Users: []string{"u3"}, Teams: []string{"dba"}},
+		})
+	})
+	update(t, store, func(tx storage.Tx) error {
+		return tx.CodeOwners().Replace([]models.CodeOwnerRule{
+			{Pattern: "/docs/", Users: []string{"u1", "u2"}},
+			{Pattern: "*.sql", Users: []string{"u3"}, Teams: []string{"dba"}},
+		})
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		rules, err := tx.CodeOwners().List()
+		if err != nil {
+			return err
+		}
+		if len(rules) != 2 {
+			t.Fatalf("Expected 2 rules, got %+v", rules)
+		}
+		if rules[0].Pattern != "/docs/" || len(rules[0].Users) != 2 || rules[0].Users[1] != "u2" || len(rules[0].Teams) != 0 {
+			t.Errorf("Unexpected first rule %+v", rules[0])
+		}
+		if rules[1].Pattern != "*.sql" || len(rules[1].Users) != 1 || rules[1].Users[0] != "u3" || len(rules[1].Teams) != 1 || rules[1].Teams[0] != "dba" {
+			t.Errorf("Unexpected second rule %+v", rules[1])
+		}
+		return nil
+	})
+}
+
+func testWebhooks(t *testing.T, store storage.Store) {
+	all := models.WebhookSubscription{URL: "http://bot.local/hook", Secret: "s1", CreatedAt: *at(0)}
+	merged := models.WebhookSubscription{URL: "http://dash.local/hook", Secret: "s2", Events: []models.EventType{models.EventPRMerged}, CreatedAt: *at(1)}
+	var first, second, third models.WebhookDelivery
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Webhooks().CreateSubscription(&all); err != nil {
+			return err
+		}
+		if err := tx.Webhooks().CreateSubscription(&merged); err != nil {
+			return err
+		}
+		first = models.WebhookDelivery{SubscriptionID: all.ID, EventID: "e1", EventType: models.EventPRCreated, Payload: []byte(`{"id":"e1"}`),
+			Status: models.DeliveryPending, NextAttemptAt: at(5), CreatedAt: *at(5)}
+		second = models.WebhookDelivery{SubscriptionID: all.ID, EventID: "e2", EventType: models.EventPRMerged, Payload: []byte(`{"id":"e2"}`),
+			Status: models.DeliveryPending, NextAttemptAt: at(2), CreatedAt: *at(2)}
+		third = models.WebhookDelivery{SubscriptionID: merged.ID, EventID: "e2", EventType: models.EventPRMerged, Payload: []byte(`{"id":"e2"}`),
+			Status: models.DeliveryPending, NextAttemptAt: at(20), CreatedAt: *at(2)}
+		for _, d := range []*models.WebhookDelivery{&first, &second, &third} {
+			if err := tx.Webhooks().EnqueueDelivery(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if all.ID == 0 || merged.ID == all.ID || first.ID == 0 || second.ID == first.ID {
+		t.Fatalf("Expected distinct ids, got %d, %d, %d, %d", all.ID, merged.ID, first.ID, second.ID)
+	}
+
+	update(t, store, func(tx storage.Tx) error {
+		duplicate := models.WebhookDelivery{SubscriptionID: all.ID, EventID: "e1", EventType: models.EventPRCreated, Payload: []byte(`{"id":"e1"}`),
+			Status: models.DeliveryPending, NextAttemptAt: at(9), CreatedAt: *at(9)}
+		if err := tx.Webhooks().EnqueueDelivery(&duplicate); err != nil {
+			return err
+		}
+		if duplicate.ID != first.ID {
+			t.Errorf("Expected duplicate delivery to keep id %d, got %d", first.ID, duplicate.ID)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		subs, err := tx.Webhooks().ListSubscriptions()
+		if err != nil {
+			return err
+		}
+		if len(subs) != 2 || subs[0].ID != all.ID || subs[0].Secret != "s1" || len(subs[0].Events) != 0 {
+			t.Fatalf("Unexpected subscriptions %+v", subs)
+		}
+		if len(subs[1].Events) != 1 || subs[1].Events[0] != models.EventPRMerged || !subs[1].CreatedAt.Equal(*at(1)) {
+			t.Errorf("Unexpected second subscription %+v", subs[1])
+		}
+
+		due, err := tx.Webhooks().DueDeliveries(*at(10), 10)
+		if err != nil {
+			return err
+		}
+		if len(due) != 2 || due[0].ID != second.ID || due[1].ID != first.ID {
+			t.Fatalf("Expected due deliveries %d, %d, got %+v", second.ID, first.ID, due)
+		}
+		if string(due[0].Payload) != `{"id":"e2"}` || due[0].EventType != models.EventPRMerged {
+			t.Errorf("Unexpected delivery %+v", due[0])
+		}
+		limited, err := tx.Webhooks().DueDeliveries(*at(10), 1)
+		if err != nil {
+			return err
+		}
+		if len(limited) != 1 {
+			t.Errorf("Expected limit to apply, got %d deliveries", len(limited))
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		second.Status = models.DeliveryDelivered
+		second.Attempts = 1
+		second.NextAttemptAt = nil
+		second.DeliveredAt = at(11)
+		if err := tx.Webhooks().UpdateDelivery(second); err != nil {
+			return err
+		}
+		first.Attempts = 1
+		first.LastError = "unexpected status 500"
+		first.NextAttemptAt = at(30)
+		if err := tx.Webhooks().UpdateDelivery(first); err != nil {
+			return err
+		}
+		missing := first
+		missing.ID = 999
+		if err := tx.Webhooks().UpdateDelivery(missing); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		due, err := tx.Webhooks().DueDeliveries(*at(25), 10)
+		if err != nil {
+			return err
+		}
+		if len(due) != 1 || due[0].ID != third.ID {
+			t.Errorf("Expected only the third delivery to be due, got %+v", due)
+		}
+		deliveries, err := tx.Webhooks().ListDeliveries(all.ID)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) != 2 || deliveries[0].ID != second.ID {
+			t.Fatalf("Expected newest delivery first, got %+v", deliveries)
+		}
+		if deliveries[0].Status != models.DeliveryDelivered || deliveries[0].DeliveredAt == nil || deliveries[0].NextAttemptAt != nil {
+			t.Errorf("Expected delivered delivery, got %+v", deliveries[0])
+		}
+		if deliveries[1].Attempts != 1 || deliveries[1].LastError != "unexpected status 500" || !deliveries[1].NextAttemptAt.Equal(*at(30)) {
+			t.Errorf("Expected retried delivery, got %+v", deliveries[1])
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Webhooks().DeleteSubscription(all.ID); err != nil {
+			return err
+		}
+		if err := tx.Webhooks().DeleteSubscription(all.ID); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+	view(t, store, func(tx storage.Tx) error {
+		deliveries, err := tx.Webhooks().ListDeliveries(all.ID)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) != 0 {
+			t.Errorf("Expected deliveries to be removed with the subscription, got %+v", deliveries)
+		}
+		return nil
+	})
+}
+
+func testOutbox(t *testing.T, store storage.Store) {
+	events := []*models.OutboxEvent{
+		{EventID: "e1", EventType: models.EventTeamCreated, Payload: []byte(`{"id":"e1"}`), OccurredAt: *at(0)},
+		{EventID: "e2", EventType: models.EventPRCreated, Payload: []byte(`{"id":"e2"}`), OccurredAt: *at(1)},
+		{EventID: "e3", EventType: models.EventPRMerged, Payload: []byte(`{"id":"e3"}`), OccurredAt: *at(2)},
+	}
+	update(t, store, func(tx storage.Tx) error {
+		for _, e := range events {
+			if err := tx.Outbox().Append(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if events[0].ID == 0 || events[1].ID <= events[0].ID || events[2].ID <= events[1].ID {
+		t.Fatalf("Expected increasing ids, got %d, %d, %d", events[0].ID, events[1].ID, events[2].ID)
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		pending, err := tx.Outbox().Pending(2)
+		if err != nil {
+			return err
+		}
+		if len(pending) != 2 || pending[0].EventID != "e1" || pending[1].EventID != "e2" {
+			t.Fatalf("Expected the first two events in order, got %+v", pending)
+		}
+		if string(pending[0].Payload) != `{"id":"e1"}` || pending[0].EventType != models.EventTeamCreated || !pending[0].OccurredAt.Equal(*at(0)) {
+			t.Errorf("Unexpected event %+v", pending[0])
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Outbox().MarkPublished(events[0].ID, *at(5)); err != nil {
+			return err
+		}
+		if err := tx.Outbox().RecordFailure(events[1].ID, "sink down"); err != nil {
+			return err
+		}
+		if err := tx.Outbox().RecordFailure(events[1].ID, "sink still down"); err != nil {
+			return err
+		}
+		if err := tx.Outbox().MarkPublished(999, *at(5)); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := tx.Outbox().RecordFailure(999, "x"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		pending, err := tx.Outbox().Pending(10)
+		if err != nil {
+			return err
+		}
+		if len(pending) != 2 || pending[0].EventID != "e2" || pending[1].EventID != "e3" {
+			t.Fatalf("Expected published event to be skipped, got %+v", pending)
+		}
+		if pending[0].Attempts != 2 || pending[0].LastError != "sink still down" || pending[0].PublishedAt != nil {
+			t.Errorf("Expected failed attempts to be recorded, got %+v", pending[0])
+		}
+		return nil
+	})
+}
+
+func testIdentities(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2")
+	update(t, store, func(tx storage.Tx) error {
+		for _, identity := range []models.UserIdentity{
+			{Provider: models.ProviderGitLab, Login: "alice", UserID: "u1"},
+			{Provider: models.ProviderGitHub, Login: "bob", UserID: "u1"},
+			{Provider: models.ProviderGitHub, Login: "alice", UserID: "u1"},
+			{Provider: models.ProviderGitHub, Login: "bob", UserID: "u2"},
+		} {
+			if err := tx.Identities().Upsert(identity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	view(t, store, func(tx storage.Tx) error {
+		identity, err := tx.Identities().Get(models.ProviderGitHub, "bob")
+		if err != nil {
+			return err
+		}
+		if identity.UserID != "u2" {
+			t.Errorf("Expected upsert to replace the user, got %+v", identity)
+		}
+		if _, err := tx.Identities().Get(models.ProviderGitLab, "bob"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for another provider, got %v", err)
+		}
+
+		identities, err := tx.Identities().List()
+		if err != nil {
+			return err
+		}
+		want := []models.UserIdentity{
+			{Provider: models.ProviderGitHub, Login: "alice", UserID: "u1"},
+			{Provider: models.ProviderGitHub, Login: "bob", UserID: "u2"},
+			{Provider: models.ProviderGitLab, Login: "alice", UserID: "u1"},
+		}
+		if fmt.Sprint(identities) != fmt.Sprint(want) {
+			t.Errorf("Expected %v, got %v", want, identities)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Identities().Delete(models.ProviderGitHub, "alice"); err != nil {
+			return err
+		}
+		if err := tx.Identities().Delete(models.ProviderGitHub, "alice"); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		return nil
+	})
+}
+
+func testTokens(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1")
+	ci := &models.APIToken{Name: "ci", Scopes: []models.Scope{models.ScopePRWrite, models.ScopeRead},
+		Hash: strings.Repeat("a", 64), CreatedAt: *at(0)}
+	ops := &models.APIToken{Name: "ops", Scopes: []models.Scope{models.ScopeAdmin},
+		Hash: strings.Repeat("b", 64), UserID: "u1", CreatedAt: *at(1)}
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Tokens().Create(ci); err != nil {
+			return err
+		}
+		return tx.Tokens().Create(ops)
+	})
+	if ci.ID == 0 || ops.ID <= ci.ID {
+		t.Fatalf("Expected increasing ids, got %d and %d", ci.ID, ops.ID)
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		token, err := tx.Tokens().GetByHash(ci.Hash)
+		if err != nil {
+			return err
+		}
+		if token.ID != ci.ID || token.Name != "ci" || fmt.Sprint(token.Scopes) != fmt.Sprint(ci.Scopes) || !token.CreatedAt.Equal(ci.CreatedAt) {
+			t.Errorf("Expected %+v, got %+v", ci, token)
+		}
+		if _, err := tx.Tokens().GetByHash(strings.Repeat("c", 64)); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for an unknown hash, got %v", err)
+		}
+
+		tokens, err := tx.Tokens().List()
+		if err != nil {
+			return err
+		}
+		if len(tokens) != 2 || tokens[0].Name != "ci" || tokens[1].Name != "ops" {
+			t.Errorf("Expected ci and ops, got %+v", tokens)
+		}
+		if len(tokens) == 2 && (tokens[0].UserID != "" || tokens[1].UserID != "u1") {
+			t.Errorf("Expected only ops to be bound to u1, got %+v", tokens)
+		}
+		return nil
+	})
+
+	update(t, store, func(tx storage.Tx) error {
+		if err := tx.Tokens().Delete(ci.ID); err != nil {
+			return err
+		}
+		if err := tx.Tokens().Delete(ci.ID); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if _, err := tx.Tokens().GetByHash(ci.Hash); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Expected deleted token to be gone, got %v", err)
+		}
+		return nil
+	})
+}
+
+func testAudit(t *testing.T, store storage.Store) {
+	entries := []*models.AuditEntry{
+		{Actor: "alice", Action: "team.create", EntityType: models.AuditEntityTeam, EntityID: "backend",
+			After: []byte(`{"team_name":"backend"}`), CreatedAt: *at(0)},
+		{Actor: "bob", Action: "pull_request.create", EntityType: models.AuditEntityPullRequest, EntityID: "pr-1",
+			After: []byte(`{"status":"OPEN"}`), CreatedAt: *at(1)},
+		{Actor: "alice", Action: "pull_request.reassign", EntityType: models.AuditEntityPullRequest, EntityID: "pr-1",
+			Before: []byte(`{"reviewers":["u2"]}`), After: []byte(`{"reviewers":["u3"]}`), CreatedAt: *at(2)},
+		{Actor: "alice", Action: "pull_request.merge", EntityType: models.AuditEntityPullRequest, EntityID: "pr-2",
+			Before: []byte(`{"status":"OPEN"}`), After: []byte(`{"status":"MERGED"}`), CreatedAt: *at(3)},
+	}
+	update(t, store, func(tx storage.Tx) error {
+		for _, e := range entries {
+			if err := tx.Audit().Append(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if entries[0].ID == 0 || entries[3].ID <= entries[0].ID {
+		t.Fatalf("Expected increasing ids, got %d and %d", entries[0].ID, entries[3].ID)
+	}
+
+	ids := func(list []models.AuditEntry) []int64 {
+		var result []int64
+		for _, e := range list {
+			result = append(result, e.ID)
+		}
+		return result
+	}
+	tests := []struct {
+		name   string
+		filter models.AuditFilter
+		want   []int64
+	}{
+		{"all newest first", models.AuditFilter{Limit: 10}, []int64{entries[3].ID, entries[2].ID, entries[1].ID, entries[0].ID}},
+		{"limit", models.AuditFilter{Limit: 2}, []int64{entries[3].ID, entries[2].ID}},
+		{"entity", models.AuditFilter{EntityType: models.AuditEntityPullRequest, EntityID: "pr-1", Limit: 10}, []int64{entries[2].ID, entries[1].ID}},
+		{"actor", models.AuditFilter{Actor: "alice", Limit: 10}, []int64{entries[3].ID, entries[2].ID, entries[0].ID}},
+		{"time range", models.AuditFilter{From: at(1), To: at(3), Limit: 10}, []int64{entries[2].ID, entries[1].ID}},
+		{"cursor", models.AuditFilter{Actor: "alice", BeforeID: entries[2].ID, Limit: 10}, []int64{entries[0].ID}},
+	}
+	view(t, store, func(tx storage.Tx) error {
+		for _, tt := range tests {
+			list, err := tx.Audit().List(tt.filter)
+			if err != nil {
+				return err
+			}
+			if fmt.Sprint(ids(list)) != fmt.Sprint(tt.want) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, ids(list))
+			}
+		}
+
+		list, err := tx.Audit().List(models.AuditFilter{EntityID: "pr-1", Actor: "alice", Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(list) != 1 || list[0].Action != "pull_request.reassign" || !list[0].CreatedAt.Equal(*at(2)) {
+			t.Fatalf("Unexpected entries %+v", list)
+		}
+		var before, after map[string][]string
+		if err := json.Unmarshal(list[0].Before, &before); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(list[0].After, &after); err != nil {
+			return err
+		}
+		if before["reviewers"][0] != "u2" || after["reviewers"][0] != "u3" {
+			t.Errorf("Unexpected before/after %s, %s", list[0].Before, list[0].After)
+		}
+
+		created, err := tx.Audit().List(models.AuditFilter{EntityType: models.AuditEntityTeam, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(created) != 1 || created[0].Before != nil {
+			t.Errorf("Expected no before state for a created entity, got %+v", created)
+		}
+		return nil
+	})
+}
+
+// appendAssignments records assignment history events
+func appendAssignments(t *testing.T, store storage.Store, events ...*models.AssignmentEvent) {
+	t.Helper()
+	update(t, store, func(tx storage.Tx) error {
+		for _, event := range events {
+			if err := tx.Assignments().Append(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func testAssignments(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3")
+	createPR(t, store, "pr-1", "u1", models.StatusOpen, at(0), "u3")
+	createPR(t, store, "pr-2", "u1", models.StatusOpen, at(0), "u2")
+
+	events := []*models.AssignmentEvent{
+		{PullRequestID: "pr-1", Action: models.AssignmentAssigned, ReviewerID: "u2", FallbackTeam: "platform",
+			Reason: models.ReasonInitial, Actor: "alice", OccurredAt: *at(0)},
+		{PullRequestID: "pr-2", Action: models.AssignmentAssigned, ReviewerID: "u2",
+			Reason: models.ReasonInitial, Actor: "alice", OccurredAt: *at(0)},
+		{PullRequestID: "pr-1", Action: models.AssignmentReassigned, ReviewerID: "u3", PreviousReviewerID: "u2",
+			Reason: models.ReasonOutOfOffice, Actor: "system", OccurredAt: *at(5)},
+	}
+	appendAssignments(t, store, events...)
+	if events[0].ID == 0 || events[2].ID <= events[0].ID {
+		t.Fatalf("Expected increasing ids, got %d and %d", events[0].ID, events[2].ID)
+	}
+
+	view(t, store, func(tx storage.Tx) error {
+		history, err := tx.Assignments().ListByPullRequest("pr-1")
+		if err != nil {
+			return err
+		}
+		if len(history) != 2 {
+			t.Fatalf("Expected 2 events for pr-1, got %+v", history)
+		}
+		for i, want := range []models.AssignmentEvent{*events[0], *events[2]} {
+			got := history[i]
+			if !got.OccurredAt.Equal(want.OccurredAt) {
+				t.Errorf("Expected event at %v, got %v", want.OccurredAt, got.OccurredAt)
+			}
+			got.OccurredAt = want.OccurredAt
+			if got != want {
+				t.Errorf("Expected %+v, got %+v", want, got)
+			}
+		}
+
+		history, err = tx.Assignments().ListByPullRequest("missing")
+		if err != nil {
+			return err
+		}
+		if len(history) != 0 {
+			t.Errorf("Expected no history, got %+v", history)
+		}
+
+		err = tx.Assignments().Append(&models.AssignmentEvent{PullRequestID: "pr-1", Action: models.AssignmentRemoved,
+			ReviewerID: "u3", Reason: models.ReasonManual, Actor: "alice", OccurredAt: *at(6)})
+		if !errors.Is(err, storage.ErrReadOnly) {
+			t.Errorf("Expected ErrReadOnly, got %v", err)
+		}
+		return nil
+	})
+}
+
+func testStats(t *testing.T, store storage.Store) {
+	seed(t, store, "backend", "u1", "u2", "u3")
+	createPR(t, store, "pr-1", "u1", models.StatusOpen, at(0), "u2", "u3")
+	createPR(t, store, "pr-2", "u1", models.StatusMerged, at(1), "u2")
+	createPR(t, store, "pr-3", "u1", models.StatusDraft, at(2))
+	createPR(t, store, "pr-4", "u2", models.StatusClosed, at(3), "u3")
+	// u3 reviewed pr-2 before it was reassigned to u2
+	assigned := func(prID, reviewerID string) *models.AssignmentEvent {
+		return &models.AssignmentEvent{PullRequestID: prID, Action: models.AssignmentAssigned, ReviewerID: reviewerID,
+			Reason: models.ReasonInitial, Actor: "system", OccurredAt: *at(0)}
+	}
+	appendAssignments(t, store,
+		assigned("pr-1", "u2"), assigned("pr-1", "u3"), assigned("pr-2", "u3"), assigned("pr-4", "u3"),
+		&models.AssignmentEvent{PullRequestID: "pr-2", Action: models.AssignmentReassigned, ReviewerID: "u2", PreviousReviewerID: "u3",
+			Reason: models.ReasonManual, Actor: "alice", OccurredAt: *at(4)},
+	)
+
+	view(t, store, func(tx storage.Tx) error {
+		prStats, err := tx.Stats().PullRequests()
+		if err != nil {
+			return err
+		}
+		expected := models.PRStatistics{
+			TotalPRs:            4,
+			OpenPRs:             1,
+			MergedPRs:           1,
+			ClosedPRs:           1,
+			DraftPRs:            1,
+			PRsWithReviewers:    3,
+			PRsWithoutReviewers: 1,
+		}
+		if prStats != expected {
+			t.Errorf("Expected %+v, got %+v", expected, prStats)
+		}
+
+		userStats, err := tx.Stats().UserAssignments()
+		if err != nil {
+			return err
+		}
+		if len(userStats) != 3 {
+			t.Fatalf("Expected stats for 3 users, got %+v", userStats)
+		}
+		u3 := models.UserAssignmentStats{UserID: "u3", Username: "name-u3", TotalAssignments: 3, ReassignedAway: 1, OpenPRs: 1, MergedPRs: 0}
+		u2 := models.UserAssignmentStats{UserID: "u2", Username: "name-u2", TotalAssignments: 2, OpenPRs: 1, MergedPRs: 1}
+		if userStats[0] != u3 || userStats[1] != u2 {
+			t.Errorf("Expected %+v, %+v first, got %+v", u3, u2, userStats)
+		}
+		if userStats[2].UserID != "u1" || userStats[2].TotalAssignments != 0 {
+			t.Errorf("Expected u1 without assignments last, got %+v", userStats[2])
+		}
+		return nil
+	})
+}